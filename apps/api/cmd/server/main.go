@@ -14,6 +14,10 @@ import (
 
 	"github.con/falasefemi2/taskflow/api/internal/config"
 	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/jobs"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+	"github.con/falasefemi2/taskflow/api/internal/scheduler"
+	"github.con/falasefemi2/taskflow/api/internal/token"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -82,8 +86,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	mail, err := mailer.New(cfg.Email)
+	if err != nil {
+		slog.Error("failed to configure mailer", "error", err)
+		os.Exit(1)
+	}
+
+	tokens, err := token.NewService(cfg.Auth)
+	if err != nil {
+		slog.Error("failed to load jwt keys", "error", err)
+		os.Exit(1)
+	}
+
+	// Email is delivered by the job worker; everything else only queues it
+	worker := jobs.NewWorker(pool, cfg.Jobs)
+	jobs.HandleEmail(worker, mail)
+	queuedMail := jobs.NewMailer(pool)
+
 	// Initialize router
-	handler := server.New(pool, cfg)
+	handler := server.New(pool, cfg, queuedMail, tokens)
 
 	// Create HTTP server using config timeouts
 	httpServer := &http.Server{
@@ -112,6 +133,13 @@ func main() {
 		}
 	}()
 
+	// Start background work
+	worker.Start()
+	periodic := scheduler.Default(pool, cfg, queuedMail)
+	if cfg.Scheduler.Enabled {
+		periodic.Start()
+	}
+
 	// Wait for interrupt
 	<-ctx.Done()
 	slog.Info("shutdown signal received")
@@ -124,5 +152,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := periodic.Stop(shutdownCtx); err != nil {
+		slog.Error("scheduler did not stop in time", "error", err)
+		os.Exit(1)
+	}
+
+	if err := worker.Stop(shutdownCtx); err != nil {
+		slog.Error("job worker did not stop in time", "error", err)
+		os.Exit(1)
+	}
+
 	slog.Info("server exited cleanly")
 }
@@ -22,6 +22,41 @@ type ActivityLog struct {
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
+type AuthThrottle struct {
+	Key         string             `json:"key"`
+	Failures    int32              `json:"failures"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Job struct {
+	ID          uuid.UUID          `json:"id"`
+	Kind        string             `json:"kind"`
+	Payload     []byte             `json:"payload"`
+	Status      string             `json:"status"`
+	Attempts    int32              `json:"attempts"`
+	MaxAttempts int32              `json:"max_attempts"`
+	RunAt       pgtype.Timestamptz `json:"run_at"`
+	UniqueKey   pgtype.Text        `json:"unique_key"`
+	LastError   pgtype.Text        `json:"last_error"`
+	LockedAt    pgtype.Timestamptz `json:"locked_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type PersonalAccessToken struct {
+	ID          uuid.UUID          `json:"id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	Name        string             `json:"name"`
+	TokenPrefix string             `json:"token_prefix"`
+	TokenHash   string             `json:"token_hash"`
+	Scopes      []string           `json:"scopes"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type Project struct {
 	ID          uuid.UUID          `json:"id"`
 	WorkspaceID uuid.UUID          `json:"workspace_id"`
@@ -35,6 +70,7 @@ type Project struct {
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 	CreatedBy   uuid.UUID          `json:"created_by"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
 }
 
 type ProjectMember struct {
@@ -46,28 +82,45 @@ type ProjectMember struct {
 }
 
 type RefreshToken struct {
-	ID        uuid.UUID          `json:"id"`
-	UserID    uuid.UUID          `json:"user_id"`
-	TokenHash string             `json:"token_hash"`
-	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ID          uuid.UUID          `json:"id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	TokenHash   string             `json:"token_hash"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UserAgent   pgtype.Text        `json:"user_agent"`
+	IpAddress   pgtype.Text        `json:"ip_address"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	DeviceLabel pgtype.Text        `json:"device_label"`
+	FamilyID    uuid.UUID          `json:"family_id"`
+	ParentID    pgtype.UUID        `json:"parent_id"`
+	RotatedAt   pgtype.Timestamptz `json:"rotated_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type SchedulerRun struct {
+	JobName        string             `json:"job_name"`
+	LastStartedAt  pgtype.Timestamptz `json:"last_started_at"`
+	LastFinishedAt pgtype.Timestamptz `json:"last_finished_at"`
+	LastError      pgtype.Text        `json:"last_error"`
 }
 
 type Task struct {
-	ID          uuid.UUID          `json:"id"`
-	ProjectID   uuid.UUID          `json:"project_id"`
-	Title       string             `json:"title"`
-	Description pgtype.Text        `json:"description"`
-	Status      string             `json:"status"`
-	Priority    string             `json:"priority"`
-	AssigneeID  pgtype.UUID        `json:"assignee_id"`
-	ReporterID  uuid.UUID          `json:"reporter_id"`
-	DueDate     pgtype.Timestamptz `json:"due_date"`
-	CompletedAt pgtype.Timestamptz `json:"completed_at"`
-	Position    int32              `json:"position"`
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
-	CreatedBy   uuid.UUID          `json:"created_by"`
+	ID             uuid.UUID          `json:"id"`
+	ProjectID      uuid.UUID          `json:"project_id"`
+	Title          string             `json:"title"`
+	Description    pgtype.Text        `json:"description"`
+	Status         string             `json:"status"`
+	Priority       string             `json:"priority"`
+	AssigneeID     pgtype.UUID        `json:"assignee_id"`
+	ReporterID     uuid.UUID          `json:"reporter_id"`
+	DueDate        pgtype.Timestamptz `json:"due_date"`
+	CompletedAt    pgtype.Timestamptz `json:"completed_at"`
+	Position       int32              `json:"position"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	CreatedBy      uuid.UUID          `json:"created_by"`
+	ReminderSentAt pgtype.Timestamptz `json:"reminder_sent_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
 }
 
 type TaskAttachment struct {
@@ -98,19 +151,58 @@ type TaskLabel struct {
 }
 
 type User struct {
-	ID                  uuid.UUID          `json:"id"`
-	Name                string             `json:"name"`
-	Email               string             `json:"email"`
-	PasswordHash        string             `json:"password_hash"`
-	AvatarUrl           pgtype.Text        `json:"avatar_url"`
-	IsVerified          bool               `json:"is_verified"`
-	VerificationToken   pgtype.Text        `json:"verification_token"`
-	ResetToken          pgtype.Text        `json:"reset_token"`
-	ResetTokenExpiresAt pgtype.Timestamptz `json:"reset_token_expires_at"`
-	Status              string             `json:"status"`
-	LastLoginAt         pgtype.Timestamptz `json:"last_login_at"`
-	CreatedAt           pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	ID                         uuid.UUID          `json:"id"`
+	Name                       string             `json:"name"`
+	Email                      string             `json:"email"`
+	PasswordHash               string             `json:"password_hash"`
+	AvatarUrl                  pgtype.Text        `json:"avatar_url"`
+	IsVerified                 bool               `json:"is_verified"`
+	VerificationToken          pgtype.Text        `json:"verification_token"`
+	Status                     string             `json:"status"`
+	LastLoginAt                pgtype.Timestamptz `json:"last_login_at"`
+	CreatedAt                  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt                  pgtype.Timestamptz `json:"updated_at"`
+	VerificationTokenExpiresAt pgtype.Timestamptz `json:"verification_token_expires_at"`
+	TotpSecret                 pgtype.Text        `json:"totp_secret"`
+	TotpEnabled                bool               `json:"totp_enabled"`
+	TotpLastUsedStep           pgtype.Int8        `json:"totp_last_used_step"`
+	LockedUntil                pgtype.Timestamptz `json:"locked_until"`
+	PendingEmail               pgtype.Text        `json:"pending_email"`
+	EmailChangeToken           pgtype.Text        `json:"email_change_token"`
+	EmailChangeTokenExpiresAt  pgtype.Timestamptz `json:"email_change_token_expires_at"`
+	Role                       string             `json:"role"`
+	TokenVersion               int32              `json:"token_version"`
+	DigestSentAt               pgtype.Timestamptz `json:"digest_sent_at"`
+	DefaultWorkspaceID         pgtype.UUID        `json:"default_workspace_id"`
+}
+
+type UserIdentity struct {
+	ID          uuid.UUID          `json:"id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	Provider    string             `json:"provider"`
+	Subject     string             `json:"subject"`
+	Email       pgtype.Text        `json:"email"`
+	LastLoginAt pgtype.Timestamptz `json:"last_login_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type UserRecoveryCode struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserToken struct {
+	ID         uuid.UUID          `json:"id"`
+	UserID     uuid.UUID          `json:"user_id"`
+	Purpose    string             `json:"purpose"`
+	TokenHash  string             `json:"token_hash"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	ConsumedAt pgtype.Timestamptz `json:"consumed_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
 type Workspace struct {
@@ -122,6 +214,22 @@ type Workspace struct {
 	Status      string             `json:"status"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+}
+
+type WorkspaceInvitation struct {
+	ID          uuid.UUID          `json:"id"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	Email       string             `json:"email"`
+	Role        string             `json:"role"`
+	InvitedBy   pgtype.UUID        `json:"invited_by"`
+	TokenHash   string             `json:"token_hash"`
+	Status      string             `json:"status"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	AcceptedBy  pgtype.UUID        `json:"accepted_by"`
+	AcceptedAt  pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
 
 type WorkspaceMember struct {
@@ -131,3 +239,9 @@ type WorkspaceMember struct {
 	Role        string             `json:"role"`
 	JoinedAt    pgtype.Timestamptz `json:"joined_at"`
 }
+
+type WorkspaceSlugHistory struct {
+	Slug        string             `json:"slug"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
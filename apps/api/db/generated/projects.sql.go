@@ -12,6 +12,34 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDeletedProjectsByWorkspaceID = `-- name: CountDeletedProjectsByWorkspaceID :one
+SELECT COUNT(*)
+FROM projects
+WHERE workspace_id = $1
+  AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountDeletedProjectsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedProjectsByWorkspaceID, workspaceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countProjectsByWorkspaceID = `-- name: CountProjectsByWorkspaceID :one
+SELECT COUNT(*)
+FROM projects
+WHERE workspace_id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) CountProjectsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countProjectsByWorkspaceID, workspaceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createProject = `-- name: CreateProject :one
 INSERT INTO projects (
     workspace_id,
@@ -26,7 +54,7 @@ INSERT INTO projects (
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by
+RETURNING id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
 `
 
 type CreateProjectParams struct {
@@ -67,24 +95,51 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CreatedBy,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const deleteProject = `-- name: DeleteProject :exec
-DELETE FROM projects
+const getDeletedProject = `-- name: GetDeletedProject :one
+SELECT id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
+FROM projects
 WHERE id = $1
+  AND workspace_id = $2
+  AND deleted_at IS NOT NULL
+LIMIT 1
 `
 
-func (q *Queries) DeleteProject(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteProject, id)
-	return err
+type GetDeletedProjectParams struct {
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+}
+
+func (q *Queries) GetDeletedProject(ctx context.Context, arg GetDeletedProjectParams) (Project, error) {
+	row := q.db.QueryRow(ctx, getDeletedProject, arg.ID, arg.WorkspaceID)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Name,
+		&i.Description,
+		&i.Status,
+		&i.Color,
+		&i.OwnerID,
+		&i.StartDate,
+		&i.DueDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
 const getProjectByID = `-- name: GetProjectByID :one
-SELECT id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by
+SELECT id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
 FROM projects
 WHERE id = $1
+  AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -104,14 +159,65 @@ func (q *Queries) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, er
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CreatedBy,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const listDeletedProjectsByWorkspaceID = `-- name: ListDeletedProjectsByWorkspaceID :many
+SELECT id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
+FROM projects
+WHERE workspace_id = $1
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $2 OFFSET $3
+`
+
+type ListDeletedProjectsByWorkspaceIDParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	Limit       int32     `json:"limit"`
+	Offset      int32     `json:"offset"`
+}
+
+func (q *Queries) ListDeletedProjectsByWorkspaceID(ctx context.Context, arg ListDeletedProjectsByWorkspaceIDParams) ([]Project, error) {
+	rows, err := q.db.Query(ctx, listDeletedProjectsByWorkspaceID, arg.WorkspaceID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.Name,
+			&i.Description,
+			&i.Status,
+			&i.Color,
+			&i.OwnerID,
+			&i.StartDate,
+			&i.DueDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CreatedBy,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProjectsByWorkspaceID = `-- name: ListProjectsByWorkspaceID :many
-SELECT id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by
+SELECT id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
 FROM projects
 WHERE workspace_id = $1
+  AND deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
 `
@@ -144,6 +250,7 @@ func (q *Queries) ListProjectsByWorkspaceID(ctx context.Context, arg ListProject
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CreatedBy,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -155,6 +262,108 @@ func (q *Queries) ListProjectsByWorkspaceID(ctx context.Context, arg ListProject
 	return items, nil
 }
 
+const purgeDeletedProjects = `-- name: PurgeDeletedProjects :execrows
+DELETE FROM projects
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedProjects(ctx context.Context, deletedBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedProjects, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreProject = `-- name: RestoreProject :one
+UPDATE projects
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NOT NULL
+RETURNING id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
+`
+
+func (q *Queries) RestoreProject(ctx context.Context, id uuid.UUID) (Project, error) {
+	row := q.db.QueryRow(ctx, restoreProject, id)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Name,
+		&i.Description,
+		&i.Status,
+		&i.Color,
+		&i.OwnerID,
+		&i.StartDate,
+		&i.DueDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreProjectsByWorkspaceID = `-- name: RestoreProjectsByWorkspaceID :exec
+UPDATE projects
+SET deleted_at = NULL, updated_at = NOW()
+WHERE workspace_id = $1
+  AND deleted_at = $2
+`
+
+type RestoreProjectsByWorkspaceIDParams struct {
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+}
+
+// Projects trashed together with their workspace share its deleted_at;
+// ones trashed earlier on their own stay in the trash.
+func (q *Queries) RestoreProjectsByWorkspaceID(ctx context.Context, arg RestoreProjectsByWorkspaceIDParams) error {
+	_, err := q.db.Exec(ctx, restoreProjectsByWorkspaceID, arg.WorkspaceID, arg.DeletedAt)
+	return err
+}
+
+const trashProject = `-- name: TrashProject :one
+UPDATE projects
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+RETURNING id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
+`
+
+func (q *Queries) TrashProject(ctx context.Context, id uuid.UUID) (Project, error) {
+	row := q.db.QueryRow(ctx, trashProject, id)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Name,
+		&i.Description,
+		&i.Status,
+		&i.Color,
+		&i.OwnerID,
+		&i.StartDate,
+		&i.DueDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const trashProjectsByWorkspaceID = `-- name: TrashProjectsByWorkspaceID :exec
+UPDATE projects
+SET deleted_at = NOW()
+WHERE workspace_id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) TrashProjectsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, trashProjectsByWorkspaceID, workspaceID)
+	return err
+}
+
 const updateProject = `-- name: UpdateProject :one
 UPDATE projects
 SET
@@ -167,7 +376,8 @@ SET
     due_date = $8,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by
+  AND deleted_at IS NULL
+RETURNING id, workspace_id, name, description, status, color, owner_id, start_date, due_date, created_at, updated_at, created_by, deleted_at
 `
 
 type UpdateProjectParams struct {
@@ -206,6 +416,7 @@ func (q *Queries) UpdateProject(ctx context.Context, arg UpdateProjectParams) (P
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CreatedBy,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -16,21 +16,43 @@ const createRefreshToken = `-- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens (
     user_id,
     token_hash,
-    expires_at
+    expires_at,
+    user_agent,
+    ip_address,
+    last_used_at,
+    device_label,
+    family_id,
+    parent_id
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, user_id, token_hash, expires_at, created_at
+RETURNING id, user_id, token_hash, expires_at, created_at, user_agent, ip_address, last_used_at, device_label, family_id, parent_id, rotated_at, revoked_at
 `
 
 type CreateRefreshTokenParams struct {
-	UserID    uuid.UUID          `json:"user_id"`
-	TokenHash string             `json:"token_hash"`
-	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UserID      uuid.UUID          `json:"user_id"`
+	TokenHash   string             `json:"token_hash"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	UserAgent   pgtype.Text        `json:"user_agent"`
+	IpAddress   pgtype.Text        `json:"ip_address"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	DeviceLabel pgtype.Text        `json:"device_label"`
+	FamilyID    uuid.UUID          `json:"family_id"`
+	ParentID    pgtype.UUID        `json:"parent_id"`
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRow(ctx, createRefreshToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	row := q.db.QueryRow(ctx, createRefreshToken,
+		arg.UserID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.LastUsedAt,
+		arg.DeviceLabel,
+		arg.FamilyID,
+		arg.ParentID,
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.ID,
@@ -38,6 +60,14 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.TokenHash,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.DeviceLabel,
+		&i.FamilyID,
+		&i.ParentID,
+		&i.RotatedAt,
+		&i.RevokedAt,
 	)
 	return i, err
 }
@@ -55,6 +85,25 @@ func (q *Queries) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error)
 	return result.RowsAffected(), nil
 }
 
+const deleteOtherRefreshTokensByUserID = `-- name: DeleteOtherRefreshTokensByUserID :execrows
+DELETE FROM refresh_tokens
+WHERE user_id = $1
+  AND family_id <> $2
+`
+
+type DeleteOtherRefreshTokensByUserIDParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	FamilyID uuid.UUID `json:"family_id"`
+}
+
+func (q *Queries) DeleteOtherRefreshTokensByUserID(ctx context.Context, arg DeleteOtherRefreshTokensByUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOtherRefreshTokensByUserID, arg.UserID, arg.FamilyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteRefreshTokenByHash = `-- name: DeleteRefreshTokenByHash :exec
 DELETE FROM refresh_tokens
 WHERE token_hash = $1
@@ -65,8 +114,47 @@ func (q *Queries) DeleteRefreshTokenByHash(ctx context.Context, tokenHash string
 	return err
 }
 
+const deleteRefreshTokenFamily = `-- name: DeleteRefreshTokenFamily :exec
+DELETE FROM refresh_tokens
+WHERE family_id = $1
+`
+
+func (q *Queries) DeleteRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRefreshTokenFamily, familyID)
+	return err
+}
+
+const deleteRefreshTokenFamilyByUserID = `-- name: DeleteRefreshTokenFamilyByUserID :execrows
+DELETE FROM refresh_tokens
+WHERE family_id = $1
+  AND user_id = $2
+`
+
+type DeleteRefreshTokenFamilyByUserIDParams struct {
+	FamilyID uuid.UUID `json:"family_id"`
+	UserID   uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteRefreshTokenFamilyByUserID(ctx context.Context, arg DeleteRefreshTokenFamilyByUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRefreshTokenFamilyByUserID, arg.FamilyID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRefreshTokensByUserID = `-- name: DeleteRefreshTokensByUserID :exec
+DELETE FROM refresh_tokens
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRefreshTokensByUserID(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRefreshTokensByUserID, userID)
+	return err
+}
+
 const getRefreshTokenByHash = `-- name: GetRefreshTokenByHash :one
-SELECT id, user_id, token_hash, expires_at, created_at
+SELECT id, user_id, token_hash, expires_at, created_at, user_agent, ip_address, last_used_at, device_label, family_id, parent_id, rotated_at, revoked_at
 FROM refresh_tokens
 WHERE token_hash = $1
 LIMIT 1
@@ -81,14 +169,25 @@ func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (
 		&i.TokenHash,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.DeviceLabel,
+		&i.FamilyID,
+		&i.ParentID,
+		&i.RotatedAt,
+		&i.RevokedAt,
 	)
 	return i, err
 }
 
 const listRefreshTokensByUserID = `-- name: ListRefreshTokensByUserID :many
-SELECT id, user_id, token_hash, expires_at, created_at
+SELECT id, user_id, token_hash, expires_at, created_at, user_agent, ip_address, last_used_at, device_label, family_id, parent_id, rotated_at, revoked_at
 FROM refresh_tokens
 WHERE user_id = $1
+  AND rotated_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
 `
@@ -114,6 +213,14 @@ func (q *Queries) ListRefreshTokensByUserID(ctx context.Context, arg ListRefresh
 			&i.TokenHash,
 			&i.ExpiresAt,
 			&i.CreatedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+			&i.DeviceLabel,
+			&i.FamilyID,
+			&i.ParentID,
+			&i.RotatedAt,
+			&i.RevokedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -124,3 +231,34 @@ func (q *Queries) ListRefreshTokensByUserID(ctx context.Context, arg ListRefresh
 	}
 	return items, nil
 }
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeRefreshTokenFamily, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET rotated_at = NOW()
+WHERE id = $1
+  AND rotated_at IS NULL
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, rotateRefreshToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
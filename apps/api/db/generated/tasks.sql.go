@@ -12,6 +12,22 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDeletedTasksByWorkspaceID = `-- name: CountDeletedTasksByWorkspaceID :one
+SELECT COUNT(*)
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE p.workspace_id = $1
+  AND p.deleted_at IS NULL
+  AND t.deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountDeletedTasksByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedTasksByWorkspaceID, workspaceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTask = `-- name: CreateTask :one
 INSERT INTO tasks (
     project_id,
@@ -28,7 +44,7 @@ INSERT INTO tasks (
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 )
-RETURNING id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by
+RETURNING id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by, reminder_sent_at, deleted_at
 `
 
 type CreateTaskParams struct {
@@ -75,24 +91,56 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CreatedBy,
+		&i.ReminderSentAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const deleteTask = `-- name: DeleteTask :exec
-DELETE FROM tasks
-WHERE id = $1
+const getDeletedTask = `-- name: GetDeletedTask :one
+SELECT t.id, t.project_id, t.title, t.description, t.status, t.priority, t.assignee_id, t.reporter_id, t.due_date, t.completed_at, t.position, t.created_at, t.updated_at, t.created_by, t.reminder_sent_at, t.deleted_at
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE t.id = $1
+  AND p.workspace_id = $2
+  AND t.deleted_at IS NOT NULL
+LIMIT 1
 `
 
-func (q *Queries) DeleteTask(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteTask, id)
-	return err
+type GetDeletedTaskParams struct {
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+}
+
+func (q *Queries) GetDeletedTask(ctx context.Context, arg GetDeletedTaskParams) (Task, error) {
+	row := q.db.QueryRow(ctx, getDeletedTask, arg.ID, arg.WorkspaceID)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Status,
+		&i.Priority,
+		&i.AssigneeID,
+		&i.ReporterID,
+		&i.DueDate,
+		&i.CompletedAt,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.ReminderSentAt,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
 const getTaskByID = `-- name: GetTaskByID :one
-SELECT id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by
+SELECT id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by, reminder_sent_at, deleted_at
 FROM tasks
 WHERE id = $1
+  AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -114,14 +162,146 @@ func (q *Queries) GetTaskByID(ctx context.Context, id uuid.UUID) (Task, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CreatedBy,
+		&i.ReminderSentAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const listDeletedTasksByWorkspaceID = `-- name: ListDeletedTasksByWorkspaceID :many
+SELECT t.id, t.project_id, t.title, t.description, t.status, t.priority, t.assignee_id, t.reporter_id, t.due_date, t.completed_at, t.position, t.created_at, t.updated_at, t.created_by, t.reminder_sent_at, t.deleted_at, p.name AS project_name
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE p.workspace_id = $1
+  AND p.deleted_at IS NULL
+  AND t.deleted_at IS NOT NULL
+ORDER BY t.deleted_at DESC, t.id
+LIMIT $2 OFFSET $3
+`
+
+type ListDeletedTasksByWorkspaceIDParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	RowLimit    int32     `json:"row_limit"`
+	RowOffset   int32     `json:"row_offset"`
+}
+
+type ListDeletedTasksByWorkspaceIDRow struct {
+	ID             uuid.UUID          `json:"id"`
+	ProjectID      uuid.UUID          `json:"project_id"`
+	Title          string             `json:"title"`
+	Description    pgtype.Text        `json:"description"`
+	Status         string             `json:"status"`
+	Priority       string             `json:"priority"`
+	AssigneeID     pgtype.UUID        `json:"assignee_id"`
+	ReporterID     uuid.UUID          `json:"reporter_id"`
+	DueDate        pgtype.Timestamptz `json:"due_date"`
+	CompletedAt    pgtype.Timestamptz `json:"completed_at"`
+	Position       int32              `json:"position"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	CreatedBy      uuid.UUID          `json:"created_by"`
+	ReminderSentAt pgtype.Timestamptz `json:"reminder_sent_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	ProjectName    string             `json:"project_name"`
+}
+
+// Tasks in a trashed project are listed through the project, which
+// restores them with it.
+func (q *Queries) ListDeletedTasksByWorkspaceID(ctx context.Context, arg ListDeletedTasksByWorkspaceIDParams) ([]ListDeletedTasksByWorkspaceIDRow, error) {
+	rows, err := q.db.Query(ctx, listDeletedTasksByWorkspaceID, arg.WorkspaceID, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeletedTasksByWorkspaceIDRow
+	for rows.Next() {
+		var i ListDeletedTasksByWorkspaceIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Title,
+			&i.Description,
+			&i.Status,
+			&i.Priority,
+			&i.AssigneeID,
+			&i.ReporterID,
+			&i.DueDate,
+			&i.CompletedAt,
+			&i.Position,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CreatedBy,
+			&i.ReminderSentAt,
+			&i.DeletedAt,
+			&i.ProjectName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDigestTasksByAssignee = `-- name: ListDigestTasksByAssignee :many
+SELECT
+    t.id, t.title, t.due_date, p.name AS project_name
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE t.assignee_id = $1
+  AND t.completed_at IS NULL
+  AND t.deleted_at IS NULL
+  AND p.deleted_at IS NULL
+  AND t.due_date <= $2
+ORDER BY t.due_date ASC
+LIMIT $3
+`
+
+type ListDigestTasksByAssigneeParams struct {
+	AssigneeID pgtype.UUID        `json:"assignee_id"`
+	DueBefore  pgtype.Timestamptz `json:"due_before"`
+	RowLimit   int32              `json:"row_limit"`
+}
+
+type ListDigestTasksByAssigneeRow struct {
+	ID          uuid.UUID          `json:"id"`
+	Title       string             `json:"title"`
+	DueDate     pgtype.Timestamptz `json:"due_date"`
+	ProjectName string             `json:"project_name"`
+}
+
+func (q *Queries) ListDigestTasksByAssignee(ctx context.Context, arg ListDigestTasksByAssigneeParams) ([]ListDigestTasksByAssigneeRow, error) {
+	rows, err := q.db.Query(ctx, listDigestTasksByAssignee, arg.AssigneeID, arg.DueBefore, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDigestTasksByAssigneeRow
+	for rows.Next() {
+		var i ListDigestTasksByAssigneeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.DueDate,
+			&i.ProjectName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTasksByProjectID = `-- name: ListTasksByProjectID :many
-SELECT id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by
+SELECT id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by, reminder_sent_at, deleted_at
 FROM tasks
 WHERE project_id = $1
+  AND deleted_at IS NULL
 ORDER BY position ASC, created_at DESC
 LIMIT $2 OFFSET $3
 `
@@ -156,6 +336,8 @@ func (q *Queries) ListTasksByProjectID(ctx context.Context, arg ListTasksByProje
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CreatedBy,
+			&i.ReminderSentAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -167,6 +349,210 @@ func (q *Queries) ListTasksByProjectID(ctx context.Context, arg ListTasksByProje
 	return items, nil
 }
 
+const listTasksDueForReminder = `-- name: ListTasksDueForReminder :many
+SELECT
+    t.id, t.title, t.due_date, p.name AS project_name, u.name AS assignee_name, u.email AS assignee_email
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+JOIN users u ON u.id = t.assignee_id
+WHERE t.completed_at IS NULL
+  AND t.deleted_at IS NULL
+  AND p.deleted_at IS NULL
+  AND t.reminder_sent_at IS NULL
+  AND t.due_date > NOW()
+  AND t.due_date <= $1
+  AND u.status IN ('active', 'locked')
+ORDER BY t.due_date ASC
+LIMIT $2
+`
+
+type ListTasksDueForReminderParams struct {
+	DueBefore pgtype.Timestamptz `json:"due_before"`
+	RowLimit  int32              `json:"row_limit"`
+}
+
+type ListTasksDueForReminderRow struct {
+	ID            uuid.UUID          `json:"id"`
+	Title         string             `json:"title"`
+	DueDate       pgtype.Timestamptz `json:"due_date"`
+	ProjectName   string             `json:"project_name"`
+	AssigneeName  string             `json:"assignee_name"`
+	AssigneeEmail string             `json:"assignee_email"`
+}
+
+func (q *Queries) ListTasksDueForReminder(ctx context.Context, arg ListTasksDueForReminderParams) ([]ListTasksDueForReminderRow, error) {
+	rows, err := q.db.Query(ctx, listTasksDueForReminder, arg.DueBefore, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTasksDueForReminderRow
+	for rows.Next() {
+		var i ListTasksDueForReminderRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.DueDate,
+			&i.ProjectName,
+			&i.AssigneeName,
+			&i.AssigneeEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markTaskReminderSent = `-- name: MarkTaskReminderSent :exec
+UPDATE tasks
+SET reminder_sent_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkTaskReminderSent(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markTaskReminderSent, id)
+	return err
+}
+
+const purgeDeletedTasks = `-- name: PurgeDeletedTasks :execrows
+DELETE FROM tasks
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedTasks(ctx context.Context, deletedBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedTasks, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreTask = `-- name: RestoreTask :one
+UPDATE tasks
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NOT NULL
+RETURNING id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by, reminder_sent_at, deleted_at
+`
+
+func (q *Queries) RestoreTask(ctx context.Context, id uuid.UUID) (Task, error) {
+	row := q.db.QueryRow(ctx, restoreTask, id)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Status,
+		&i.Priority,
+		&i.AssigneeID,
+		&i.ReporterID,
+		&i.DueDate,
+		&i.CompletedAt,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.ReminderSentAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreTasksByProjectID = `-- name: RestoreTasksByProjectID :exec
+UPDATE tasks
+SET deleted_at = NULL, updated_at = NOW()
+WHERE project_id = $1
+  AND deleted_at = $2
+`
+
+type RestoreTasksByProjectIDParams struct {
+	ProjectID uuid.UUID          `json:"project_id"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+}
+
+func (q *Queries) RestoreTasksByProjectID(ctx context.Context, arg RestoreTasksByProjectIDParams) error {
+	_, err := q.db.Exec(ctx, restoreTasksByProjectID, arg.ProjectID, arg.DeletedAt)
+	return err
+}
+
+const restoreTasksByWorkspaceID = `-- name: RestoreTasksByWorkspaceID :exec
+UPDATE tasks
+SET deleted_at = NULL, updated_at = NOW()
+WHERE project_id IN (SELECT id FROM projects WHERE workspace_id = $1)
+  AND deleted_at = $2
+`
+
+type RestoreTasksByWorkspaceIDParams struct {
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+}
+
+func (q *Queries) RestoreTasksByWorkspaceID(ctx context.Context, arg RestoreTasksByWorkspaceIDParams) error {
+	_, err := q.db.Exec(ctx, restoreTasksByWorkspaceID, arg.WorkspaceID, arg.DeletedAt)
+	return err
+}
+
+const trashTask = `-- name: TrashTask :one
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+RETURNING id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by, reminder_sent_at, deleted_at
+`
+
+func (q *Queries) TrashTask(ctx context.Context, id uuid.UUID) (Task, error) {
+	row := q.db.QueryRow(ctx, trashTask, id)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Status,
+		&i.Priority,
+		&i.AssigneeID,
+		&i.ReporterID,
+		&i.DueDate,
+		&i.CompletedAt,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.ReminderSentAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const trashTasksByProjectID = `-- name: TrashTasksByProjectID :exec
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE project_id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) TrashTasksByProjectID(ctx context.Context, projectID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, trashTasksByProjectID, projectID)
+	return err
+}
+
+const trashTasksByWorkspaceID = `-- name: TrashTasksByWorkspaceID :exec
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE project_id IN (SELECT id FROM projects WHERE workspace_id = $1)
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) TrashTasksByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, trashTasksByWorkspaceID, workspaceID)
+	return err
+}
+
 const updateTask = `-- name: UpdateTask :one
 UPDATE tasks
 SET
@@ -179,9 +565,12 @@ SET
     due_date = $8,
     completed_at = $9,
     position = $10,
+    -- A new due date needs a new reminder
+    reminder_sent_at = CASE WHEN due_date IS DISTINCT FROM $8 THEN NULL ELSE reminder_sent_at END,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by
+  AND deleted_at IS NULL
+RETURNING id, project_id, title, description, status, priority, assignee_id, reporter_id, due_date, completed_at, position, created_at, updated_at, created_by, reminder_sent_at, deleted_at
 `
 
 type UpdateTaskParams struct {
@@ -226,6 +615,8 @@ func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) (Task, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CreatedBy,
+		&i.ReminderSentAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
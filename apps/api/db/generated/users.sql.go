@@ -12,6 +12,171 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeUser = `-- name: AnonymizeUser :exec
+UPDATE users
+SET
+    name = 'Deleted user',
+    email = 'deleted+' || id::text || '@deleted.invalid',
+    password_hash = '',
+    avatar_url = NULL,
+    is_verified = false,
+    verification_token = NULL,
+    verification_token_expires_at = NULL,
+    pending_email = NULL,
+    email_change_token = NULL,
+    email_change_token_expires_at = NULL,
+    totp_secret = NULL,
+    totp_enabled = false,
+    status = 'deleted',
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) AnonymizeUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, anonymizeUser, id)
+	return err
+}
+
+const clearExpiredEmailChanges = `-- name: ClearExpiredEmailChanges :execrows
+UPDATE users
+SET
+    pending_email = NULL,
+    email_change_token = NULL,
+    email_change_token_expires_at = NULL
+WHERE email_change_token_expires_at < NOW()
+`
+
+func (q *Queries) ClearExpiredEmailChanges(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, clearExpiredEmailChanges)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const clearExpiredVerificationTokens = `-- name: ClearExpiredVerificationTokens :execrows
+UPDATE users
+SET
+    verification_token = NULL,
+    verification_token_expires_at = NULL
+WHERE verification_token_expires_at < NOW()
+`
+
+func (q *Queries) ClearExpiredVerificationTokens(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, clearExpiredVerificationTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const clearUserDefaultWorkspace = `-- name: ClearUserDefaultWorkspace :exec
+UPDATE users
+SET default_workspace_id = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND default_workspace_id = $2
+`
+
+type ClearUserDefaultWorkspaceParams struct {
+	ID                 uuid.UUID   `json:"id"`
+	DefaultWorkspaceID pgtype.UUID `json:"default_workspace_id"`
+}
+
+func (q *Queries) ClearUserDefaultWorkspace(ctx context.Context, arg ClearUserDefaultWorkspaceParams) error {
+	_, err := q.db.Exec(ctx, clearUserDefaultWorkspace, arg.ID, arg.DefaultWorkspaceID)
+	return err
+}
+
+const confirmUserEmailChange = `-- name: ConfirmUserEmailChange :one
+UPDATE users
+SET
+    email = pending_email,
+    is_verified = true,
+    pending_email = NULL,
+    email_change_token = NULL,
+    email_change_token_expires_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND pending_email IS NOT NULL
+RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
+`
+
+func (q *Queries) ConfirmUserEmailChange(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, confirmUserEmailChange, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.VerificationToken,
+		&i.Status,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
+	)
+	return i, err
+}
+
+const consumeUserTOTPStep = `-- name: ConsumeUserTOTPStep :execrows
+UPDATE users
+SET totp_last_used_step = $1::bigint
+WHERE id = $2
+  AND (totp_last_used_step IS NULL OR totp_last_used_step < $1::bigint)
+`
+
+type ConsumeUserTOTPStepParams struct {
+	Step int64     `json:"step"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func (q *Queries) ConsumeUserTOTPStep(ctx context.Context, arg ConsumeUserTOTPStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, consumeUserTOTPStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE ($1::text IS NULL
+       OR name ILIKE '%' || $1::text || '%'
+       OR email ILIKE '%' || $1::text || '%')
+  AND ($2::text IS NULL OR status = $2::text)
+  AND ($3::text IS NULL OR role = $3::text)
+`
+
+type CountUsersParams struct {
+	Search pgtype.Text `json:"search"`
+	Status pgtype.Text `json:"status"`
+	Role   pgtype.Text `json:"role"`
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers, arg.Search, arg.Status, arg.Role)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     name,
@@ -21,7 +186,7 @@ INSERT INTO users (
 ) VALUES (
     $1, $2, $3, $4
 )
-RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, reset_token, reset_token_expires_at, status, last_login_at, created_at, updated_at
+RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
 `
 
 type CreateUserParams struct {
@@ -47,12 +212,22 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.AvatarUrl,
 		&i.IsVerified,
 		&i.VerificationToken,
-		&i.ResetToken,
-		&i.ResetTokenExpiresAt,
 		&i.Status,
 		&i.LastLoginAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
 	)
 	return i, err
 }
@@ -67,8 +242,59 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+UPDATE users
+SET
+    totp_secret = NULL,
+    totp_enabled = false,
+    totp_last_used_step = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, disableUserTOTP, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :exec
+UPDATE users
+SET
+    totp_enabled = true,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, enableUserTOTP, id)
+	return err
+}
+
+const getUserAuthState = `-- name: GetUserAuthState :one
+SELECT token_version, status, role
+FROM users
+WHERE id = $1
+`
+
+type GetUserAuthStateRow struct {
+	TokenVersion int32  `json:"token_version"`
+	Status       string `json:"status"`
+	Role         string `json:"role"`
+}
+
+func (q *Queries) GetUserAuthState(ctx context.Context, id uuid.UUID) (GetUserAuthStateRow, error) {
+	row := q.db.QueryRow(ctx, getUserAuthState, id)
+	var i GetUserAuthStateRow
+	err := row.Scan(
+		&i.TokenVersion,
+		&i.Status,
+		&i.Role,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, reset_token, reset_token_expires_at, status, last_login_at, created_at, updated_at
+SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
 FROM users
 WHERE email = $1
 LIMIT 1
@@ -85,18 +311,66 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.AvatarUrl,
 		&i.IsVerified,
 		&i.VerificationToken,
-		&i.ResetToken,
-		&i.ResetTokenExpiresAt,
 		&i.Status,
 		&i.LastLoginAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
+	)
+	return i, err
+}
+
+const getUserByEmailChangeToken = `-- name: GetUserByEmailChangeToken :one
+SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
+FROM users
+WHERE email_change_token = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByEmailChangeToken(ctx context.Context, emailChangeToken pgtype.Text) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmailChangeToken, emailChangeToken)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.VerificationToken,
+		&i.Status,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, reset_token, reset_token_expires_at, status, last_login_at, created_at, updated_at
+SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
 FROM users
 WHERE id = $1
 LIMIT 1
@@ -113,30 +387,171 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.AvatarUrl,
 		&i.IsVerified,
 		&i.VerificationToken,
-		&i.ResetToken,
-		&i.ResetTokenExpiresAt,
 		&i.Status,
 		&i.LastLoginAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
+	)
+	return i, err
+}
+
+const getUserByVerificationToken = `-- name: GetUserByVerificationToken :one
+SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
+FROM users
+WHERE verification_token = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByVerificationToken(ctx context.Context, verificationToken pgtype.Text) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByVerificationToken, verificationToken)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.VerificationToken,
+		&i.Status,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
 	)
 	return i, err
 }
 
+const incrementUserTokenVersion = `-- name: IncrementUserTokenVersion :exec
+UPDATE users
+SET token_version = token_version + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, incrementUserTokenVersion, id)
+	return err
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, reset_token, reset_token_expires_at, status, last_login_at, created_at, updated_at
+SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
 FROM users
+WHERE ($1::text IS NULL
+       OR name ILIKE '%' || $1::text || '%'
+       OR email ILIKE '%' || $1::text || '%')
+  AND ($2::text IS NULL OR status = $2::text)
+  AND ($3::text IS NULL OR role = $3::text)
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+LIMIT $4 OFFSET $5
 `
 
 type ListUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Search    pgtype.Text `json:"search"`
+	Status    pgtype.Text `json:"status"`
+	Role      pgtype.Text `json:"role"`
+	RowLimit  int32       `json:"row_limit"`
+	RowOffset int32       `json:"row_offset"`
 }
 
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.Search,
+		arg.Status,
+		arg.Role,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.PasswordHash,
+			&i.AvatarUrl,
+			&i.IsVerified,
+			&i.VerificationToken,
+			&i.Status,
+			&i.LastLoginAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.VerificationTokenExpiresAt,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.TotpLastUsedStep,
+			&i.LockedUntil,
+			&i.PendingEmail,
+			&i.EmailChangeToken,
+			&i.EmailChangeTokenExpiresAt,
+			&i.Role,
+			&i.TokenVersion,
+			&i.DigestSentAt,
+			&i.DefaultWorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDueDigest = `-- name: ListUsersDueDigest :many
+SELECT id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
+FROM users
+WHERE status IN ('active', 'locked')
+  AND (digest_sent_at IS NULL OR digest_sent_at < $1)
+  AND EXISTS (
+      SELECT 1
+      FROM tasks t
+      JOIN projects p ON p.id = t.project_id
+      WHERE t.assignee_id = users.id
+        AND t.completed_at IS NULL
+        AND t.deleted_at IS NULL
+        AND p.deleted_at IS NULL
+        AND t.due_date <= $2
+  )
+LIMIT $3
+`
+
+type ListUsersDueDigestParams struct {
+	SentBefore pgtype.Timestamptz `json:"sent_before"`
+	DueBefore  pgtype.Timestamptz `json:"due_before"`
+	RowLimit   int32              `json:"row_limit"`
+}
+
+func (q *Queries) ListUsersDueDigest(ctx context.Context, arg ListUsersDueDigestParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersDueDigest, arg.SentBefore, arg.DueBefore, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -152,12 +567,22 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.AvatarUrl,
 			&i.IsVerified,
 			&i.VerificationToken,
-			&i.ResetToken,
-			&i.ResetTokenExpiresAt,
 			&i.Status,
 			&i.LastLoginAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.VerificationTokenExpiresAt,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.TotpLastUsedStep,
+			&i.LockedUntil,
+			&i.PendingEmail,
+			&i.EmailChangeToken,
+			&i.EmailChangeTokenExpiresAt,
+			&i.Role,
+			&i.TokenVersion,
+			&i.DigestSentAt,
+			&i.DefaultWorkspaceID,
 		); err != nil {
 			return nil, err
 		}
@@ -169,26 +594,261 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
-const setUserResetToken = `-- name: SetUserResetToken :exec
+const lockUser = `-- name: LockUser :exec
+UPDATE users
+SET
+    status = 'locked',
+    locked_until = $2,
+    updated_at = NOW()
+WHERE id = $1
+  AND status IN ('active', 'locked')
+`
+
+type LockUserParams struct {
+	ID          uuid.UUID          `json:"id"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+}
+
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) error {
+	_, err := q.db.Exec(ctx, lockUser, arg.ID, arg.LockedUntil)
+	return err
+}
+
+const markUserDigestSent = `-- name: MarkUserDigestSent :exec
+UPDATE users
+SET digest_sent_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkUserDigestSent(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markUserDigestSent, id)
+	return err
+}
+
+const markUserVerified = `-- name: MarkUserVerified :exec
+UPDATE users
+SET
+    is_verified = true,
+    verification_token = NULL,
+    verification_token_expires_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkUserVerified(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markUserVerified, id)
+	return err
+}
+
+const reactivateUser = `-- name: ReactivateUser :execrows
+UPDATE users
+SET
+    status = 'active',
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND status = 'suspended'
+`
+
+func (q *Queries) ReactivateUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, reactivateUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserDefaultWorkspace = `-- name: SetUserDefaultWorkspace :one
+UPDATE users
+SET default_workspace_id = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
+`
+
+type SetUserDefaultWorkspaceParams struct {
+	ID                 uuid.UUID   `json:"id"`
+	DefaultWorkspaceID pgtype.UUID `json:"default_workspace_id"`
+}
+
+func (q *Queries) SetUserDefaultWorkspace(ctx context.Context, arg SetUserDefaultWorkspaceParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserDefaultWorkspace, arg.ID, arg.DefaultWorkspaceID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.VerificationToken,
+		&i.Status,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
+	)
+	return i, err
+}
+
+const setUserPendingEmail = `-- name: SetUserPendingEmail :exec
+UPDATE users
+SET
+    pending_email = $2,
+    email_change_token = $3,
+    email_change_token_expires_at = $4,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserPendingEmailParams struct {
+	ID                        uuid.UUID          `json:"id"`
+	PendingEmail              pgtype.Text        `json:"pending_email"`
+	EmailChangeToken          pgtype.Text        `json:"email_change_token"`
+	EmailChangeTokenExpiresAt pgtype.Timestamptz `json:"email_change_token_expires_at"`
+}
+
+func (q *Queries) SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) error {
+	_, err := q.db.Exec(ctx, setUserPendingEmail,
+		arg.ID,
+		arg.PendingEmail,
+		arg.EmailChangeToken,
+		arg.EmailChangeTokenExpiresAt,
+	)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET
+    role = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.VerificationToken,
+		&i.Status,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
+	)
+	return i, err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET
+    totp_secret = $2,
+    totp_enabled = false,
+    totp_last_used_step = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserTOTPSecretParams struct {
+	ID         uuid.UUID   `json:"id"`
+	TotpSecret pgtype.Text `json:"totp_secret"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) error {
+	_, err := q.db.Exec(ctx, setUserTOTPSecret, arg.ID, arg.TotpSecret)
+	return err
+}
+
+const setUserVerificationToken = `-- name: SetUserVerificationToken :exec
 UPDATE users
 SET
-    reset_token = $2,
-    reset_token_expires_at = $3,
+    verification_token = $2,
+    verification_token_expires_at = $3,
     updated_at = NOW()
 WHERE id = $1
 `
 
-type SetUserResetTokenParams struct {
-	ID                  uuid.UUID          `json:"id"`
-	ResetToken          pgtype.Text        `json:"reset_token"`
-	ResetTokenExpiresAt pgtype.Timestamptz `json:"reset_token_expires_at"`
+type SetUserVerificationTokenParams struct {
+	ID                         uuid.UUID          `json:"id"`
+	VerificationToken          pgtype.Text        `json:"verification_token"`
+	VerificationTokenExpiresAt pgtype.Timestamptz `json:"verification_token_expires_at"`
 }
 
-func (q *Queries) SetUserResetToken(ctx context.Context, arg SetUserResetTokenParams) error {
-	_, err := q.db.Exec(ctx, setUserResetToken, arg.ID, arg.ResetToken, arg.ResetTokenExpiresAt)
+func (q *Queries) SetUserVerificationToken(ctx context.Context, arg SetUserVerificationTokenParams) error {
+	_, err := q.db.Exec(ctx, setUserVerificationToken, arg.ID, arg.VerificationToken, arg.VerificationTokenExpiresAt)
 	return err
 }
 
+const suspendUser = `-- name: SuspendUser :execrows
+UPDATE users
+SET
+    status = 'suspended',
+    updated_at = NOW()
+WHERE id = $1
+  AND status IN ('active', 'locked')
+`
+
+func (q *Queries) SuspendUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, suspendUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const unlockUser = `-- name: UnlockUser :execrows
+UPDATE users
+SET
+    status = 'active',
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND status = 'locked'
+`
+
+func (q *Queries) UnlockUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, unlockUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
@@ -197,26 +857,22 @@ SET
     avatar_url = $4,
     is_verified = $5,
     verification_token = $6,
-    reset_token = $7,
-    reset_token_expires_at = $8,
-    status = $9,
-    last_login_at = $10,
+    status = $7,
+    last_login_at = $8,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, reset_token, reset_token_expires_at, status, last_login_at, created_at, updated_at
+RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
 `
 
 type UpdateUserParams struct {
-	ID                  uuid.UUID          `json:"id"`
-	Name                string             `json:"name"`
-	Email               string             `json:"email"`
-	AvatarUrl           pgtype.Text        `json:"avatar_url"`
-	IsVerified          bool               `json:"is_verified"`
-	VerificationToken   pgtype.Text        `json:"verification_token"`
-	ResetToken          pgtype.Text        `json:"reset_token"`
-	ResetTokenExpiresAt pgtype.Timestamptz `json:"reset_token_expires_at"`
-	Status              string             `json:"status"`
-	LastLoginAt         pgtype.Timestamptz `json:"last_login_at"`
+	ID                uuid.UUID          `json:"id"`
+	Name              string             `json:"name"`
+	Email             string             `json:"email"`
+	AvatarUrl         pgtype.Text        `json:"avatar_url"`
+	IsVerified        bool               `json:"is_verified"`
+	VerificationToken pgtype.Text        `json:"verification_token"`
+	Status            string             `json:"status"`
+	LastLoginAt       pgtype.Timestamptz `json:"last_login_at"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
@@ -227,8 +883,6 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.AvatarUrl,
 		arg.IsVerified,
 		arg.VerificationToken,
-		arg.ResetToken,
-		arg.ResetTokenExpiresAt,
 		arg.Status,
 		arg.LastLoginAt,
 	)
@@ -241,12 +895,22 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.AvatarUrl,
 		&i.IsVerified,
 		&i.VerificationToken,
-		&i.ResetToken,
-		&i.ResetTokenExpiresAt,
 		&i.Status,
 		&i.LastLoginAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
 	)
 	return i, err
 }
@@ -264,14 +928,14 @@ func (q *Queries) UpdateUserLastLogin(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const updateUserPassword = `-- name: UpdateUserPassword :exec
+const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users
 SET
     password_hash = $2,
-    reset_token = NULL,
-    reset_token_expires_at = NULL,
+    token_version = token_version + 1,
     updated_at = NOW()
 WHERE id = $1
+RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
 `
 
 type UpdateUserPasswordParams struct {
@@ -279,7 +943,98 @@ type UpdateUserPasswordParams struct {
 	PasswordHash string    `json:"password_hash"`
 }
 
-func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
-	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.VerificationToken,
+		&i.Status,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    name = $2,
+    avatar_url = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, email, password_hash, avatar_url, is_verified, verification_token, status, last_login_at, created_at, updated_at, verification_token_expires_at, totp_secret, totp_enabled, totp_last_used_step, locked_until, pending_email, email_change_token, email_change_token_expires_at, role, token_version, digest_sent_at, default_workspace_id
+`
+
+type UpdateUserProfileParams struct {
+	ID        uuid.UUID   `json:"id"`
+	Name      string      `json:"name"`
+	AvatarUrl pgtype.Text `json:"avatar_url"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserProfile, arg.ID, arg.Name, arg.AvatarUrl)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.AvatarUrl,
+		&i.IsVerified,
+		&i.VerificationToken,
+		&i.Status,
+		&i.LastLoginAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerificationTokenExpiresAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastUsedStep,
+		&i.LockedUntil,
+		&i.PendingEmail,
+		&i.EmailChangeToken,
+		&i.EmailChangeTokenExpiresAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.DigestSentAt,
+		&i.DefaultWorkspaceID,
+	)
+	return i, err
+}
+
+const upgradeUserPasswordHash = `-- name: UpgradeUserPasswordHash :exec
+UPDATE users
+SET password_hash = $1
+WHERE id = $2
+  AND password_hash = $3
+`
+
+type UpgradeUserPasswordHashParams struct {
+	NewHash string    `json:"new_hash"`
+	ID      uuid.UUID `json:"id"`
+	OldHash string    `json:"old_hash"`
+}
+
+func (q *Queries) UpgradeUserPasswordHash(ctx context.Context, arg UpgradeUserPasswordHashParams) error {
+	_, err := q.db.Exec(ctx, upgradeUserPasswordHash, arg.NewHash, arg.ID, arg.OldHash)
 	return err
 }
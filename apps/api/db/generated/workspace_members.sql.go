@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const addWorkspaceMember = `-- name: AddWorkspaceMember :one
@@ -41,6 +42,55 @@ func (q *Queries) AddWorkspaceMember(ctx context.Context, arg AddWorkspaceMember
 	return i, err
 }
 
+const countWorkspaceMembers = `-- name: CountWorkspaceMembers :one
+SELECT COUNT(*)
+FROM workspace_members
+WHERE workspace_id = $1
+`
+
+func (q *Queries) CountWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countWorkspaceMembers, workspaceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWorkspaceOwners = `-- name: CountWorkspaceOwners :one
+SELECT COUNT(*)
+FROM workspace_members
+WHERE workspace_id = $1
+  AND role = 'owner'
+`
+
+func (q *Queries) CountWorkspaceOwners(ctx context.Context, workspaceID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countWorkspaceOwners, workspaceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getNextWorkspaceOwner = `-- name: GetNextWorkspaceOwner :one
+SELECT user_id
+FROM workspace_members
+WHERE workspace_id = $1
+  AND user_id <> $2
+  AND role = 'owner'
+ORDER BY joined_at ASC
+LIMIT 1
+`
+
+type GetNextWorkspaceOwnerParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) GetNextWorkspaceOwner(ctx context.Context, arg GetNextWorkspaceOwnerParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, getNextWorkspaceOwner, arg.WorkspaceID, arg.UserID)
+	var userID uuid.UUID
+	err := row.Scan(&userID)
+	return userID, err
+}
+
 const getWorkspaceMember = `-- name: GetWorkspaceMember :one
 SELECT id, workspace_id, user_id, role, joined_at
 FROM workspace_members
@@ -68,10 +118,11 @@ func (q *Queries) GetWorkspaceMember(ctx context.Context, arg GetWorkspaceMember
 }
 
 const listWorkspaceMembers = `-- name: ListWorkspaceMembers :many
-SELECT id, workspace_id, user_id, role, joined_at
-FROM workspace_members
-WHERE workspace_id = $1
-ORDER BY joined_at DESC
+SELECT wm.id, wm.workspace_id, wm.user_id, wm.role, wm.joined_at, u.name, u.email, u.avatar_url
+FROM workspace_members wm
+JOIN users u ON u.id = wm.user_id
+WHERE wm.workspace_id = $1
+ORDER BY wm.joined_at ASC
 LIMIT $2 OFFSET $3
 `
 
@@ -81,21 +132,35 @@ type ListWorkspaceMembersParams struct {
 	Offset      int32     `json:"offset"`
 }
 
-func (q *Queries) ListWorkspaceMembers(ctx context.Context, arg ListWorkspaceMembersParams) ([]WorkspaceMember, error) {
+type ListWorkspaceMembersRow struct {
+	ID          uuid.UUID          `json:"id"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	Role        string             `json:"role"`
+	JoinedAt    pgtype.Timestamptz `json:"joined_at"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	AvatarUrl   pgtype.Text        `json:"avatar_url"`
+}
+
+func (q *Queries) ListWorkspaceMembers(ctx context.Context, arg ListWorkspaceMembersParams) ([]ListWorkspaceMembersRow, error) {
 	rows, err := q.db.Query(ctx, listWorkspaceMembers, arg.WorkspaceID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceMember
+	var items []ListWorkspaceMembersRow
 	for rows.Next() {
-		var i WorkspaceMember
+		var i ListWorkspaceMembersRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.WorkspaceID,
 			&i.UserID,
 			&i.Role,
 			&i.JoinedAt,
+			&i.Name,
+			&i.Email,
+			&i.AvatarUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -107,6 +172,16 @@ func (q *Queries) ListWorkspaceMembers(ctx context.Context, arg ListWorkspaceMem
 	return items, nil
 }
 
+const removeUserFromAllWorkspaces = `-- name: RemoveUserFromAllWorkspaces :exec
+DELETE FROM workspace_members
+WHERE user_id = $1
+`
+
+func (q *Queries) RemoveUserFromAllWorkspaces(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, removeUserFromAllWorkspaces, userID)
+	return err
+}
+
 const removeWorkspaceMember = `-- name: RemoveWorkspaceMember :exec
 DELETE FROM workspace_members
 WHERE workspace_id = $1
@@ -12,6 +12,50 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDeletedWorkspacesForUser = `-- name: CountDeletedWorkspacesForUser :one
+SELECT COUNT(*)
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = $1
+  AND wm.role = ANY($2::text[])
+  AND w.deleted_at IS NOT NULL
+`
+
+type CountDeletedWorkspacesForUserParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Roles  []string  `json:"roles"`
+}
+
+func (q *Queries) CountDeletedWorkspacesForUser(ctx context.Context, arg CountDeletedWorkspacesForUserParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedWorkspacesForUser, arg.UserID, arg.Roles)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWorkspacesForUser = `-- name: CountWorkspacesForUser :one
+SELECT COUNT(*)
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = $1
+  AND w.deleted_at IS NULL
+  AND ($2::text IS NULL OR w.status = $2::text)
+  AND ($3::text IS NULL OR w.name ILIKE '%' || $3::text || '%')
+`
+
+type CountWorkspacesForUserParams struct {
+	UserID uuid.UUID   `json:"user_id"`
+	Status pgtype.Text `json:"status"`
+	Search pgtype.Text `json:"search"`
+}
+
+func (q *Queries) CountWorkspacesForUser(ctx context.Context, arg CountWorkspacesForUserParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countWorkspacesForUser, arg.UserID, arg.Status, arg.Search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createWorkspace = `-- name: CreateWorkspace :one
 INSERT INTO workspaces (
     name,
@@ -22,7 +66,7 @@ INSERT INTO workspaces (
 ) VALUES (
     $1, $2, $3, $4, $5
 )
-RETURNING id, name, slug, description, owner_id, status, created_at, updated_at
+RETURNING id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
 `
 
 type CreateWorkspaceParams struct {
@@ -51,24 +95,41 @@ func (q *Queries) CreateWorkspace(ctx context.Context, arg CreateWorkspaceParams
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const deleteWorkspace = `-- name: DeleteWorkspace :exec
-DELETE FROM workspaces
+const getDeletedWorkspaceByID = `-- name: GetDeletedWorkspaceByID :one
+SELECT id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
+FROM workspaces
 WHERE id = $1
+  AND deleted_at IS NOT NULL
+LIMIT 1
 `
 
-func (q *Queries) DeleteWorkspace(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteWorkspace, id)
-	return err
+func (q *Queries) GetDeletedWorkspaceByID(ctx context.Context, id uuid.UUID) (Workspace, error) {
+	row := q.db.QueryRow(ctx, getDeletedWorkspaceByID, id)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.OwnerID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
 const getWorkspaceByID = `-- name: GetWorkspaceByID :one
-SELECT id, name, slug, description, owner_id, status, created_at, updated_at
+SELECT id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
 FROM workspaces
 WHERE id = $1
+  AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -84,14 +145,16 @@ func (q *Queries) GetWorkspaceByID(ctx context.Context, id uuid.UUID) (Workspace
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getWorkspaceBySlug = `-- name: GetWorkspaceBySlug :one
-SELECT id, name, slug, description, owner_id, status, created_at, updated_at
+SELECT id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
 FROM workspaces
 WHERE slug = $1
+  AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -107,15 +170,110 @@ func (q *Queries) GetWorkspaceBySlug(ctx context.Context, slug string) (Workspac
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getWorkspaceForUpdate = `-- name: GetWorkspaceForUpdate :one
+SELECT id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
+FROM workspaces
+WHERE id = $1
+  AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetWorkspaceForUpdate(ctx context.Context, id uuid.UUID) (Workspace, error) {
+	row := q.db.QueryRow(ctx, getWorkspaceForUpdate, id)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.OwnerID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const listDeletedWorkspacesForUser = `-- name: ListDeletedWorkspacesForUser :many
+SELECT w.id, w.name, w.slug, w.description, w.owner_id, w.status, w.created_at, w.updated_at, w.deleted_at, wm.role
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = $1
+  AND wm.role = ANY($2::text[])
+  AND w.deleted_at IS NOT NULL
+ORDER BY w.deleted_at DESC, w.id
+LIMIT $3 OFFSET $4
+`
+
+type ListDeletedWorkspacesForUserParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Roles     []string  `json:"roles"`
+	RowLimit  int32     `json:"row_limit"`
+	RowOffset int32     `json:"row_offset"`
+}
+
+type ListDeletedWorkspacesForUserRow struct {
+	ID          uuid.UUID          `json:"id"`
+	Name        string             `json:"name"`
+	Slug        string             `json:"slug"`
+	Description pgtype.Text        `json:"description"`
+	OwnerID     uuid.UUID          `json:"owner_id"`
+	Status      string             `json:"status"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+	Role        string             `json:"role"`
+}
+
+// Only memberships whose role is in roles are listed, so the trash shows
+// just the workspaces the user could restore.
+func (q *Queries) ListDeletedWorkspacesForUser(ctx context.Context, arg ListDeletedWorkspacesForUserParams) ([]ListDeletedWorkspacesForUserRow, error) {
+	rows, err := q.db.Query(ctx, listDeletedWorkspacesForUser,
+		arg.UserID,
+		arg.Roles,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeletedWorkspacesForUserRow
+	for rows.Next() {
+		var i ListDeletedWorkspacesForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Slug,
+			&i.Description,
+			&i.OwnerID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listWorkspacesByOwnerID = `-- name: ListWorkspacesByOwnerID :many
-SELECT id, name, slug, description, owner_id, status, created_at, updated_at
+SELECT id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
 FROM workspaces
 WHERE owner_id = $1
-ORDER BY created_at DESC
+ORDER BY created_at DESC, id
 LIMIT $2 OFFSET $3
 `
 
@@ -125,6 +283,7 @@ type ListWorkspacesByOwnerIDParams struct {
 	Offset  int32     `json:"offset"`
 }
 
+// Includes workspaces in the trash, which are still owned until purged.
 func (q *Queries) ListWorkspacesByOwnerID(ctx context.Context, arg ListWorkspacesByOwnerIDParams) ([]Workspace, error) {
 	rows, err := q.db.Query(ctx, listWorkspacesByOwnerID, arg.OwnerID, arg.Limit, arg.Offset)
 	if err != nil {
@@ -143,6 +302,86 @@ func (q *Queries) ListWorkspacesByOwnerID(ctx context.Context, arg ListWorkspace
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkspacesForUser = `-- name: ListWorkspacesForUser :many
+SELECT w.id, w.name, w.slug, w.description, w.owner_id, w.status, w.created_at, w.updated_at, w.deleted_at, wm.role
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = $1
+  AND w.deleted_at IS NULL
+  AND ($2::text IS NULL OR w.status = $2::text)
+  AND ($3::text IS NULL OR w.name ILIKE '%' || $3::text || '%')
+ORDER BY
+    CASE WHEN $4::text = 'name' THEN LOWER(w.name) END ASC,
+    CASE WHEN $4::text = '-name' THEN LOWER(w.name) END DESC,
+    CASE WHEN $4::text = 'created_at' THEN w.created_at END ASC,
+    CASE WHEN $4::text = '-created_at' THEN w.created_at END DESC,
+    CASE WHEN $4::text = 'updated_at' THEN w.updated_at END ASC,
+    CASE WHEN $4::text = '-updated_at' THEN w.updated_at END DESC,
+    w.id
+LIMIT $5 OFFSET $6
+`
+
+type ListWorkspacesForUserParams struct {
+	UserID    uuid.UUID   `json:"user_id"`
+	Status    pgtype.Text `json:"status"`
+	Search    pgtype.Text `json:"search"`
+	Sort      string      `json:"sort"`
+	RowLimit  int32       `json:"row_limit"`
+	RowOffset int32       `json:"row_offset"`
+}
+
+type ListWorkspacesForUserRow struct {
+	ID          uuid.UUID          `json:"id"`
+	Name        string             `json:"name"`
+	Slug        string             `json:"slug"`
+	Description pgtype.Text        `json:"description"`
+	OwnerID     uuid.UUID          `json:"owner_id"`
+	Status      string             `json:"status"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+	Role        string             `json:"role"`
+}
+
+func (q *Queries) ListWorkspacesForUser(ctx context.Context, arg ListWorkspacesForUserParams) ([]ListWorkspacesForUserRow, error) {
+	rows, err := q.db.Query(ctx, listWorkspacesForUser,
+		arg.UserID,
+		arg.Status,
+		arg.Search,
+		arg.Sort,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWorkspacesForUserRow
+	for rows.Next() {
+		var i ListWorkspacesForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Slug,
+			&i.Description,
+			&i.OwnerID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -154,17 +393,96 @@ func (q *Queries) ListWorkspacesByOwnerID(ctx context.Context, arg ListWorkspace
 	return items, nil
 }
 
+const purgeDeletedWorkspaces = `-- name: PurgeDeletedWorkspaces :execrows
+DELETE FROM workspaces
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedWorkspaces(ctx context.Context, deletedBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedWorkspaces, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreWorkspace = `-- name: RestoreWorkspace :one
+UPDATE workspaces
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NOT NULL
+RETURNING id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
+`
+
+func (q *Queries) RestoreWorkspace(ctx context.Context, id uuid.UUID) (Workspace, error) {
+	row := q.db.QueryRow(ctx, restoreWorkspace, id)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.OwnerID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setWorkspaceOwner = `-- name: SetWorkspaceOwner :exec
+UPDATE workspaces
+SET owner_id = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetWorkspaceOwnerParams struct {
+	ID      uuid.UUID `json:"id"`
+	OwnerID uuid.UUID `json:"owner_id"`
+}
+
+func (q *Queries) SetWorkspaceOwner(ctx context.Context, arg SetWorkspaceOwnerParams) error {
+	_, err := q.db.Exec(ctx, setWorkspaceOwner, arg.ID, arg.OwnerID)
+	return err
+}
+
+const trashWorkspace = `-- name: TrashWorkspace :one
+UPDATE workspaces
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+RETURNING id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
+`
+
+func (q *Queries) TrashWorkspace(ctx context.Context, id uuid.UUID) (Workspace, error) {
+	row := q.db.QueryRow(ctx, trashWorkspace, id)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.OwnerID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateWorkspace = `-- name: UpdateWorkspace :one
 UPDATE workspaces
 SET
     name = $2,
     slug = $3,
     description = $4,
-    owner_id = $5,
-    status = $6,
+    status = $5,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, slug, description, owner_id, status, created_at, updated_at
+  AND deleted_at IS NULL
+RETURNING id, name, slug, description, owner_id, status, created_at, updated_at, deleted_at
 `
 
 type UpdateWorkspaceParams struct {
@@ -172,17 +490,17 @@ type UpdateWorkspaceParams struct {
 	Name        string      `json:"name"`
 	Slug        string      `json:"slug"`
 	Description pgtype.Text `json:"description"`
-	OwnerID     uuid.UUID   `json:"owner_id"`
 	Status      string      `json:"status"`
 }
 
+// owner_id is left alone; only SetWorkspaceOwner, under the row lock taken
+// by a transfer, changes it.
 func (q *Queries) UpdateWorkspace(ctx context.Context, arg UpdateWorkspaceParams) (Workspace, error) {
 	row := q.db.QueryRow(ctx, updateWorkspace,
 		arg.ID,
 		arg.Name,
 		arg.Slug,
 		arg.Description,
-		arg.OwnerID,
 		arg.Status,
 	)
 	var i Workspace
@@ -195,6 +513,7 @@ func (q *Queries) UpdateWorkspace(ctx context.Context, arg UpdateWorkspaceParams
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
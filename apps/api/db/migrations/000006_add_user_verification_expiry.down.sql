@@ -0,0 +1,2 @@
+-- 000006_add_user_verification_expiry.down.sql
+ALTER TABLE users DROP COLUMN IF EXISTS verification_token_expires_at;
@@ -0,0 +1,4 @@
+-- 000006_add_user_verification_expiry.up.sql
+
+ALTER TABLE users
+    ADD COLUMN verification_token_expires_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+-- 000007_add_refresh_token_metadata.down.sql
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS device_label,
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS user_agent;
@@ -0,0 +1,7 @@
+-- 000007_add_refresh_token_metadata.up.sql
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN user_agent TEXT,
+    ADD COLUMN ip_address VARCHAR(45),
+    ADD COLUMN last_used_at TIMESTAMPTZ,
+    ADD COLUMN device_label VARCHAR(100);
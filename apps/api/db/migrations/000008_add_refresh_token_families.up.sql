@@ -0,0 +1,9 @@
+-- 000008_add_refresh_token_families.up.sql
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    ADD COLUMN parent_id UUID REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+    ADD COLUMN rotated_at TIMESTAMPTZ,
+    ADD COLUMN revoked_at TIMESTAMPTZ;
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
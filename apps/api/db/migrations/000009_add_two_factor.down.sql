@@ -0,0 +1,6 @@
+-- 000009_add_two_factor.down.sql
+DROP TABLE IF EXISTS user_recovery_codes;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_last_used_step,
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,3 @@
+-- 000010_add_auth_throttling.down.sql
+DROP TABLE IF EXISTS auth_throttles;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
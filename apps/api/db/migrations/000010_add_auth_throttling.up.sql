@@ -0,0 +1,13 @@
+-- 000010_add_auth_throttling.up.sql
+
+ALTER TABLE users
+    ADD COLUMN locked_until TIMESTAMPTZ;
+
+CREATE TABLE auth_throttles (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_auth_throttles_updated_at ON auth_throttles(updated_at);
@@ -0,0 +1,2 @@
+-- 000012_create_personal_access_tokens.down.sql
+DROP TABLE IF EXISTS personal_access_tokens;
@@ -0,0 +1,5 @@
+-- 000013_add_user_email_change.down.sql
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email_change_token_expires_at,
+    DROP COLUMN IF EXISTS email_change_token,
+    DROP COLUMN IF EXISTS pending_email;
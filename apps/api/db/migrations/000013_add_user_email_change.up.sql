@@ -0,0 +1,6 @@
+-- 000013_add_user_email_change.up.sql
+
+ALTER TABLE users
+    ADD COLUMN pending_email VARCHAR(255),
+    ADD COLUMN email_change_token TEXT,
+    ADD COLUMN email_change_token_expires_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- 000014_add_user_role.down.sql
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,6 @@
+-- 000014_add_user_role.up.sql
+
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+
+CREATE INDEX idx_users_role ON users(role);
@@ -0,0 +1,5 @@
+-- 000015_create_user_tokens.down.sql
+ALTER TABLE users
+    ADD COLUMN reset_token TEXT,
+    ADD COLUMN reset_token_expires_at TIMESTAMPTZ;
+DROP TABLE IF EXISTS user_tokens;
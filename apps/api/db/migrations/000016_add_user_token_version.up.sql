@@ -0,0 +1,6 @@
+-- 000016_add_user_token_version.up.sql
+
+-- Access tokens carry the version they were issued under. Bumping it
+-- invalidates every access token issued before.
+ALTER TABLE users
+    ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
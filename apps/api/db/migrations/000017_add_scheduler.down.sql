@@ -0,0 +1,5 @@
+-- 000017_add_scheduler.down.sql
+ALTER TABLE users DROP COLUMN IF EXISTS digest_sent_at;
+DROP INDEX IF EXISTS idx_tasks_open_due_date;
+ALTER TABLE tasks DROP COLUMN IF EXISTS reminder_sent_at;
+DROP TABLE IF EXISTS scheduler_runs;
@@ -0,0 +1,16 @@
+-- 000017_add_scheduler.up.sql
+
+CREATE TABLE scheduler_runs (
+    job_name VARCHAR(100) PRIMARY KEY,
+    last_started_at TIMESTAMPTZ NOT NULL,
+    last_finished_at TIMESTAMPTZ,
+    last_error TEXT
+);
+
+ALTER TABLE tasks
+    ADD COLUMN reminder_sent_at TIMESTAMPTZ;
+
+CREATE INDEX idx_tasks_open_due_date ON tasks(due_date) WHERE completed_at IS NULL;
+
+ALTER TABLE users
+    ADD COLUMN digest_sent_at TIMESTAMPTZ;
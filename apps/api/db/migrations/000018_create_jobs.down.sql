@@ -0,0 +1,2 @@
+-- 000018_create_jobs.down.sql
+DROP TABLE IF EXISTS jobs;
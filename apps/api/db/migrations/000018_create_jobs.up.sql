@@ -0,0 +1,26 @@
+-- 000018_create_jobs.up.sql
+
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    -- pending | running | completed | dead
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    unique_key VARCHAR(255),
+    last_error TEXT,
+    locked_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_jobs_pending_run_at ON jobs(run_at) WHERE status = 'pending';
+CREATE INDEX idx_jobs_running_locked_at ON jobs(locked_at) WHERE status = 'running';
+
+-- A unique key only blocks duplicates while the earlier job is still queued
+-- or running.
+CREATE UNIQUE INDEX idx_jobs_kind_unique_key ON jobs(kind, unique_key)
+    WHERE unique_key IS NOT NULL AND status IN ('pending', 'running');
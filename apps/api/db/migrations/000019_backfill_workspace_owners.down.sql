@@ -0,0 +1,3 @@
+-- 000019_backfill_workspace_owners.down.sql
+DROP INDEX IF EXISTS idx_workspace_members_user_id;
+ALTER TABLE workspace_members DROP CONSTRAINT IF EXISTS workspace_members_role_check;
@@ -0,0 +1,12 @@
+-- 000019_backfill_workspace_owners.up.sql
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, owner_id, 'owner'
+FROM workspaces
+ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = 'owner';
+
+ALTER TABLE workspace_members
+    ADD CONSTRAINT workspace_members_role_check
+    CHECK (role IN ('owner', 'admin', 'member', 'guest'));
+
+CREATE INDEX idx_workspace_members_user_id ON workspace_members(user_id);
@@ -0,0 +1,2 @@
+-- 000020_create_workspace_invitations.down.sql
+DROP TABLE IF EXISTS workspace_invitations;
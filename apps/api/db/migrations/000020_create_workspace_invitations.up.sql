@@ -0,0 +1,23 @@
+-- 000020_create_workspace_invitations.up.sql
+
+CREATE TABLE workspace_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member', 'guest')),
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'revoked')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    accepted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One open invitation per address and workspace; resending reuses it.
+CREATE UNIQUE INDEX idx_workspace_invitations_pending_email
+    ON workspace_invitations(workspace_id, email)
+    WHERE status = 'pending';
+
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations(workspace_id, created_at);
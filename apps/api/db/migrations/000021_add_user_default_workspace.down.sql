@@ -0,0 +1,3 @@
+-- 000021_add_user_default_workspace.down.sql
+DROP INDEX IF EXISTS idx_workspaces_status;
+ALTER TABLE users DROP COLUMN IF EXISTS default_workspace_id;
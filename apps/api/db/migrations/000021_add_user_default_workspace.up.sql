@@ -0,0 +1,6 @@
+-- 000021_add_user_default_workspace.up.sql
+
+ALTER TABLE users
+    ADD COLUMN default_workspace_id UUID REFERENCES workspaces(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_workspaces_status ON workspaces(status);
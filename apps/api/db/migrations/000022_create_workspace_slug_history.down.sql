@@ -0,0 +1,2 @@
+-- 000022_create_workspace_slug_history.down.sql
+DROP TABLE IF EXISTS workspace_slug_history;
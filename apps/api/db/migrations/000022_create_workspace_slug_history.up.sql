@@ -0,0 +1,9 @@
+-- 000022_create_workspace_slug_history.up.sql
+
+CREATE TABLE workspace_slug_history (
+    slug VARCHAR(100) PRIMARY KEY,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_workspace_slug_history_workspace_id ON workspace_slug_history(workspace_id);
@@ -0,0 +1,11 @@
+-- 000023_add_soft_delete.up.sql
+
+ALTER TABLE workspaces ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE projects ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Only trashed rows are looked up by deleted_at (trash listings and the
+-- retention job), so keep the indexes small.
+CREATE INDEX idx_workspaces_deleted_at ON workspaces(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_projects_deleted_at ON projects(workspace_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_tasks_deleted_at ON tasks(project_id, deleted_at) WHERE deleted_at IS NOT NULL;
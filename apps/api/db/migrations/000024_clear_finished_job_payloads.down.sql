@@ -0,0 +1,2 @@
+-- 000024_clear_finished_job_payloads.down.sql
+-- Cleared payloads cannot be restored, so there is nothing to undo.
@@ -0,0 +1,7 @@
+-- 000024_clear_finished_job_payloads.up.sql
+
+-- Finished jobs no longer keep their payload, which for emails holds links
+-- with raw tokens. Clear the ones written before that.
+UPDATE jobs
+SET payload = '{}'
+WHERE status IN ('completed', 'dead');
@@ -0,0 +1,37 @@
+-- name: GetAuthThrottle :one
+SELECT *
+FROM auth_throttles
+WHERE key = $1
+LIMIT 1;
+
+-- name: RecordAuthFailure :one
+INSERT INTO auth_throttles (
+    key,
+    failures
+) VALUES (
+    sqlc.arg(key), 1
+)
+ON CONFLICT (key) DO UPDATE
+SET
+    failures = CASE
+        WHEN auth_throttles.updated_at < sqlc.arg(window_start) THEN 1
+        ELSE auth_throttles.failures + 1
+    END,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: SetAuthThrottleLock :exec
+UPDATE auth_throttles
+SET
+    locked_until = $2,
+    updated_at = NOW()
+WHERE key = $1;
+
+-- name: DeleteAuthThrottle :exec
+DELETE FROM auth_throttles
+WHERE key = $1;
+
+-- name: DeleteStaleAuthThrottles :exec
+DELETE FROM auth_throttles
+WHERE updated_at < $1
+  AND (locked_until IS NULL OR locked_until < NOW());
@@ -0,0 +1,78 @@
+-- name: EnqueueJob :execrows
+INSERT INTO jobs (
+    kind,
+    payload,
+    max_attempts,
+    run_at,
+    unique_key
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (kind, unique_key) WHERE unique_key IS NOT NULL AND status IN ('pending', 'running')
+DO NOTHING;
+
+-- name: ClaimJobs :many
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    locked_at = NOW(),
+    updated_at = NOW()
+WHERE id IN (
+    SELECT id
+    FROM jobs
+    WHERE status = 'pending'
+      AND run_at <= NOW()
+    ORDER BY run_at ASC
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET
+    status = 'completed',
+    payload = '{}',
+    last_error = NULL,
+    locked_at = NULL,
+    completed_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: RetryJob :exec
+UPDATE jobs
+SET
+    status = 'pending',
+    run_at = $2,
+    last_error = $3,
+    locked_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: KillJob :exec
+UPDATE jobs
+SET
+    status = 'dead',
+    payload = '{}',
+    last_error = $2,
+    locked_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: RequeueStalledJobs :execrows
+UPDATE jobs
+SET
+    status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+    payload = CASE WHEN attempts >= max_attempts THEN '{}' ELSE payload END,
+    run_at = NOW(),
+    last_error = 'worker stopped while running the job',
+    locked_at = NULL,
+    updated_at = NOW()
+WHERE status = 'running'
+  AND locked_at < sqlc.arg(locked_before);
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs
+WHERE status IN ('completed', 'dead')
+  AND updated_at < sqlc.arg(updated_before);
@@ -0,0 +1,45 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (
+    user_id,
+    name,
+    token_prefix,
+    token_hash,
+    scopes,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT pat.*, u.status AS user_status
+FROM personal_access_tokens pat
+JOIN users u ON u.id = pat.user_id
+WHERE pat.token_hash = $1
+LIMIT 1;
+
+-- name: ListPersonalAccessTokensByUserID :many
+SELECT *
+FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: CountPersonalAccessTokensByUserID :one
+SELECT COUNT(*)
+FROM personal_access_tokens
+WHERE user_id = $1;
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens
+SET last_used_at = NOW()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
+
+-- name: DeletePersonalAccessToken :execrows
+DELETE FROM personal_access_tokens
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: DeletePersonalAccessTokensByUserID :exec
+DELETE FROM personal_access_tokens
+WHERE user_id = $1;
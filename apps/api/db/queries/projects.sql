@@ -18,15 +18,23 @@ RETURNING *;
 SELECT *
 FROM projects
 WHERE id = $1
+  AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: ListProjectsByWorkspaceID :many
 SELECT *
 FROM projects
 WHERE workspace_id = $1
+  AND deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: CountProjectsByWorkspaceID :one
+SELECT COUNT(*)
+FROM projects
+WHERE workspace_id = $1
+  AND deleted_at IS NULL;
+
 -- name: UpdateProject :one
 UPDATE projects
 SET
@@ -39,9 +47,60 @@ SET
     due_date = $8,
     updated_at = NOW()
 WHERE id = $1
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: TrashProject :one
+UPDATE projects
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: TrashProjectsByWorkspaceID :exec
+UPDATE projects
+SET deleted_at = NOW()
+WHERE workspace_id = $1
+  AND deleted_at IS NULL;
+
+-- name: GetDeletedProject :one
+SELECT *
+FROM projects
+WHERE id = $1
+  AND workspace_id = $2
+  AND deleted_at IS NOT NULL
+LIMIT 1;
+
+-- name: ListDeletedProjectsByWorkspaceID :many
+SELECT *
+FROM projects
+WHERE workspace_id = $1
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $2 OFFSET $3;
+
+-- name: CountDeletedProjectsByWorkspaceID :one
+SELECT COUNT(*)
+FROM projects
+WHERE workspace_id = $1
+  AND deleted_at IS NOT NULL;
+
+-- name: RestoreProject :one
+UPDATE projects
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NOT NULL
 RETURNING *;
 
--- name: DeleteProject :exec
+-- name: RestoreProjectsByWorkspaceID :exec
+-- Projects trashed together with their workspace share its deleted_at;
+-- ones trashed earlier on their own stay in the trash.
+UPDATE projects
+SET deleted_at = NULL, updated_at = NOW()
+WHERE workspace_id = $1
+  AND deleted_at = $2;
+
+-- name: PurgeDeletedProjects :execrows
 DELETE FROM projects
-WHERE id = $1;
+WHERE deleted_at < sqlc.arg(deleted_before);
 
@@ -2,9 +2,15 @@
 INSERT INTO refresh_tokens (
     user_id,
     token_hash,
-    expires_at
+    expires_at,
+    user_agent,
+    ip_address,
+    last_used_at,
+    device_label,
+    family_id,
+    parent_id
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
 RETURNING *;
 
@@ -18,14 +24,47 @@ LIMIT 1;
 SELECT *
 FROM refresh_tokens
 WHERE user_id = $1
+  AND rotated_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET rotated_at = NOW()
+WHERE id = $1
+  AND rotated_at IS NULL
+  AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1
+  AND revoked_at IS NULL;
+
 -- name: DeleteRefreshTokenByHash :exec
 DELETE FROM refresh_tokens
 WHERE token_hash = $1;
 
+-- name: DeleteRefreshTokenFamily :exec
+DELETE FROM refresh_tokens
+WHERE family_id = $1;
+
+-- name: DeleteRefreshTokenFamilyByUserID :execrows
+DELETE FROM refresh_tokens
+WHERE family_id = $1
+  AND user_id = $2;
+
+-- name: DeleteOtherRefreshTokensByUserID :execrows
+DELETE FROM refresh_tokens
+WHERE user_id = $1
+  AND family_id <> $2;
+
 -- name: DeleteExpiredRefreshTokens :execrows
 DELETE FROM refresh_tokens
 WHERE expires_at < NOW();
 
+-- name: DeleteRefreshTokensByUserID :exec
+DELETE FROM refresh_tokens
+WHERE user_id = $1;
@@ -0,0 +1,20 @@
+-- name: ClaimSchedulerRun :execrows
+INSERT INTO scheduler_runs (job_name, last_started_at)
+VALUES (sqlc.arg(job_name), NOW())
+ON CONFLICT (job_name) DO UPDATE
+SET last_started_at = NOW()
+WHERE scheduler_runs.last_started_at <= sqlc.arg(due_before);
+
+-- name: FinishSchedulerRun :exec
+UPDATE scheduler_runs
+SET
+    last_finished_at = NOW(),
+    last_error = $2
+WHERE job_name = $1;
+
+-- name: ReleaseSchedulerRun :exec
+-- Gives up a claimed run without recording it, so the job is due again
+-- straight away.
+UPDATE scheduler_runs
+SET last_started_at = 'epoch'
+WHERE job_name = $1;
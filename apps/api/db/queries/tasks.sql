@@ -20,12 +20,14 @@ RETURNING *;
 SELECT *
 FROM tasks
 WHERE id = $1
+  AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: ListTasksByProjectID :many
 SELECT *
 FROM tasks
 WHERE project_id = $1
+  AND deleted_at IS NULL
 ORDER BY position ASC, created_at DESC
 LIMIT $2 OFFSET $3;
 
@@ -41,11 +43,123 @@ SET
     due_date = $8,
     completed_at = $9,
     position = $10,
+    -- A new due date needs a new reminder
+    reminder_sent_at = CASE WHEN due_date IS DISTINCT FROM $8 THEN NULL ELSE reminder_sent_at END,
     updated_at = NOW()
 WHERE id = $1
+  AND deleted_at IS NULL
 RETURNING *;
 
--- name: DeleteTask :exec
+-- name: TrashTask :one
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: TrashTasksByProjectID :exec
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE project_id = $1
+  AND deleted_at IS NULL;
+
+-- name: TrashTasksByWorkspaceID :exec
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE project_id IN (SELECT id FROM projects WHERE workspace_id = $1)
+  AND deleted_at IS NULL;
+
+-- name: GetDeletedTask :one
+SELECT t.*
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE t.id = sqlc.arg(id)
+  AND p.workspace_id = sqlc.arg(workspace_id)
+  AND t.deleted_at IS NOT NULL
+LIMIT 1;
+
+-- name: ListDeletedTasksByWorkspaceID :many
+-- Tasks in a trashed project are listed through the project, which
+-- restores them with it.
+SELECT t.*, p.name AS project_name
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE p.workspace_id = sqlc.arg(workspace_id)
+  AND p.deleted_at IS NULL
+  AND t.deleted_at IS NOT NULL
+ORDER BY t.deleted_at DESC, t.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountDeletedTasksByWorkspaceID :one
+SELECT COUNT(*)
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE p.workspace_id = $1
+  AND p.deleted_at IS NULL
+  AND t.deleted_at IS NOT NULL;
+
+-- name: RestoreTask :one
+UPDATE tasks
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: RestoreTasksByProjectID :exec
+UPDATE tasks
+SET deleted_at = NULL, updated_at = NOW()
+WHERE project_id = $1
+  AND deleted_at = $2;
+
+-- name: RestoreTasksByWorkspaceID :exec
+UPDATE tasks
+SET deleted_at = NULL, updated_at = NOW()
+WHERE project_id IN (SELECT id FROM projects WHERE workspace_id = $1)
+  AND deleted_at = $2;
+
+-- name: PurgeDeletedTasks :execrows
 DELETE FROM tasks
+WHERE deleted_at < sqlc.arg(deleted_before);
+
+
+-- name: ListTasksDueForReminder :many
+SELECT
+    t.id,
+    t.title,
+    t.due_date,
+    p.name AS project_name,
+    u.name AS assignee_name,
+    u.email AS assignee_email
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+JOIN users u ON u.id = t.assignee_id
+WHERE t.completed_at IS NULL
+  AND t.deleted_at IS NULL
+  AND p.deleted_at IS NULL
+  AND t.reminder_sent_at IS NULL
+  AND t.due_date > NOW()
+  AND t.due_date <= sqlc.arg(due_before)
+  AND u.status IN ('active', 'locked')
+ORDER BY t.due_date ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: MarkTaskReminderSent :exec
+UPDATE tasks
+SET reminder_sent_at = NOW()
 WHERE id = $1;
 
+-- name: ListDigestTasksByAssignee :many
+SELECT
+    t.id,
+    t.title,
+    t.due_date,
+    p.name AS project_name
+FROM tasks t
+JOIN projects p ON p.id = t.project_id
+WHERE t.assignee_id = sqlc.arg(assignee_id)
+  AND t.completed_at IS NULL
+  AND t.deleted_at IS NULL
+  AND p.deleted_at IS NULL
+  AND t.due_date <= sqlc.arg(due_before)
+ORDER BY t.due_date ASC
+LIMIT sqlc.arg(row_limit);
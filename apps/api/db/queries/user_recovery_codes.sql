@@ -0,0 +1,23 @@
+-- name: CreateRecoveryCodes :exec
+INSERT INTO user_recovery_codes (
+    user_id,
+    code_hash
+)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(code_hashes)::text[]);
+
+-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)
+FROM user_recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodesByUserID :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1;
@@ -0,0 +1,42 @@
+-- name: CreateUserToken :one
+INSERT INTO user_tokens (
+    user_id,
+    purpose,
+    token_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetActiveUserToken :one
+SELECT *
+FROM user_tokens
+WHERE token_hash = $1
+  AND purpose = $2
+  AND consumed_at IS NULL
+  AND expires_at > NOW()
+LIMIT 1;
+
+-- name: ConsumeUserToken :one
+UPDATE user_tokens
+SET consumed_at = NOW()
+WHERE token_hash = $1
+  AND purpose = $2
+  AND consumed_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteUserTokens :exec
+DELETE FROM user_tokens
+WHERE user_id = $1
+  AND purpose = $2;
+
+-- name: DeleteUserTokensByUserID :exec
+DELETE FROM user_tokens
+WHERE user_id = $1;
+
+-- name: DeleteExpiredUserTokens :execrows
+DELETE FROM user_tokens
+WHERE expires_at < NOW()
+   OR consumed_at IS NOT NULL;
@@ -24,8 +24,22 @@ LIMIT 1;
 -- name: ListUsers :many
 SELECT *
 FROM users
+WHERE (sqlc.narg(search)::text IS NULL
+       OR name ILIKE '%' || sqlc.narg(search)::text || '%'
+       OR email ILIKE '%' || sqlc.narg(search)::text || '%')
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  AND (sqlc.narg(role)::text IS NULL OR role = sqlc.narg(role)::text)
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE (sqlc.narg(search)::text IS NULL
+       OR name ILIKE '%' || sqlc.narg(search)::text || '%'
+       OR email ILIKE '%' || sqlc.narg(search)::text || '%')
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  AND (sqlc.narg(role)::text IS NULL OR role = sqlc.narg(role)::text);
 
 -- name: UpdateUser :one
 UPDATE users
@@ -35,31 +49,37 @@ SET
     avatar_url = $4,
     is_verified = $5,
     verification_token = $6,
-    reset_token = $7,
-    reset_token_expires_at = $8,
-    status = $9,
-    last_login_at = $10,
+    status = $7,
+    last_login_at = $8,
     updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
--- name: UpdateUserPassword :exec
+-- name: UpdateUserPassword :one
 UPDATE users
 SET
     password_hash = $2,
-    reset_token = NULL,
-    reset_token_expires_at = NULL,
+    token_version = token_version + 1,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1
+RETURNING *;
 
--- name: SetUserResetToken :exec
+-- name: IncrementUserTokenVersion :exec
 UPDATE users
-SET
-    reset_token = $2,
-    reset_token_expires_at = $3,
-    updated_at = NOW()
+SET token_version = token_version + 1
 WHERE id = $1;
 
+-- name: GetUserAuthState :one
+SELECT token_version, status, role
+FROM users
+WHERE id = $1;
+
+-- name: UpgradeUserPasswordHash :exec
+UPDATE users
+SET password_hash = sqlc.arg(new_hash)
+WHERE id = sqlc.arg(id)
+  AND password_hash = sqlc.arg(old_hash);
+
 -- name: UpdateUserLastLogin :exec
 UPDATE users
 SET
@@ -70,3 +90,208 @@ WHERE id = $1;
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1;
+
+-- name: GetUserByVerificationToken :one
+SELECT *
+FROM users
+WHERE verification_token = $1
+LIMIT 1;
+
+-- name: SetUserVerificationToken :exec
+UPDATE users
+SET
+    verification_token = $2,
+    verification_token_expires_at = $3,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkUserVerified :exec
+UPDATE users
+SET
+    is_verified = true,
+    verification_token = NULL,
+    verification_token_expires_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET
+    totp_secret = $2,
+    totp_enabled = false,
+    totp_last_used_step = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET
+    totp_enabled = true,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET
+    totp_secret = NULL,
+    totp_enabled = false,
+    totp_last_used_step = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: ConsumeUserTOTPStep :execrows
+UPDATE users
+SET totp_last_used_step = sqlc.arg(step)::bigint
+WHERE id = sqlc.arg(id)
+  AND (totp_last_used_step IS NULL OR totp_last_used_step < sqlc.arg(step)::bigint);
+
+-- name: LockUser :exec
+UPDATE users
+SET
+    status = 'locked',
+    locked_until = $2,
+    updated_at = NOW()
+WHERE id = $1
+  AND status IN ('active', 'locked');
+
+-- name: UnlockUser :execrows
+UPDATE users
+SET
+    status = 'active',
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND status = 'locked';
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    name = $2,
+    avatar_url = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserPendingEmail :exec
+UPDATE users
+SET
+    pending_email = $2,
+    email_change_token = $3,
+    email_change_token_expires_at = $4,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetUserByEmailChangeToken :one
+SELECT *
+FROM users
+WHERE email_change_token = $1
+LIMIT 1;
+
+-- name: ConfirmUserEmailChange :one
+UPDATE users
+SET
+    email = pending_email,
+    is_verified = true,
+    pending_email = NULL,
+    email_change_token = NULL,
+    email_change_token_expires_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND pending_email IS NOT NULL
+RETURNING *;
+
+-- name: AnonymizeUser :exec
+UPDATE users
+SET
+    name = 'Deleted user',
+    email = 'deleted+' || id::text || '@deleted.invalid',
+    password_hash = '',
+    avatar_url = NULL,
+    is_verified = false,
+    verification_token = NULL,
+    verification_token_expires_at = NULL,
+    pending_email = NULL,
+    email_change_token = NULL,
+    email_change_token_expires_at = NULL,
+    totp_secret = NULL,
+    totp_enabled = false,
+    status = 'deleted',
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SuspendUser :execrows
+UPDATE users
+SET
+    status = 'suspended',
+    updated_at = NOW()
+WHERE id = $1
+  AND status IN ('active', 'locked');
+
+-- name: ReactivateUser :execrows
+UPDATE users
+SET
+    status = 'active',
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND status = 'suspended';
+
+-- name: SetUserRole :one
+UPDATE users
+SET
+    role = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ClearExpiredEmailChanges :execrows
+UPDATE users
+SET
+    pending_email = NULL,
+    email_change_token = NULL,
+    email_change_token_expires_at = NULL
+WHERE email_change_token_expires_at < NOW();
+
+-- name: ClearExpiredVerificationTokens :execrows
+UPDATE users
+SET
+    verification_token = NULL,
+    verification_token_expires_at = NULL
+WHERE verification_token_expires_at < NOW();
+
+-- name: ListUsersDueDigest :many
+SELECT *
+FROM users
+WHERE status IN ('active', 'locked')
+  AND (digest_sent_at IS NULL OR digest_sent_at < sqlc.arg(sent_before))
+  AND EXISTS (
+      SELECT 1
+      FROM tasks t
+      JOIN projects p ON p.id = t.project_id
+      WHERE t.assignee_id = users.id
+        AND t.completed_at IS NULL
+        AND t.deleted_at IS NULL
+        AND p.deleted_at IS NULL
+        AND t.due_date <= sqlc.arg(due_before)
+  )
+LIMIT sqlc.arg(row_limit);
+
+-- name: MarkUserDigestSent :exec
+UPDATE users
+SET digest_sent_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserDefaultWorkspace :one
+UPDATE users
+SET default_workspace_id = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ClearUserDefaultWorkspace :exec
+UPDATE users
+SET default_workspace_id = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND default_workspace_id = $2;
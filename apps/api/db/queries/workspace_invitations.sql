@@ -0,0 +1,82 @@
+-- name: CreateWorkspaceInvitation :one
+-- A pending invitation that has expired is reused for the new one. While an
+-- unexpired one is pending nothing is written and no row is returned.
+INSERT INTO workspace_invitations (
+    workspace_id,
+    email,
+    role,
+    invited_by,
+    token_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (workspace_id, email) WHERE status = 'pending'
+DO UPDATE SET
+    role = EXCLUDED.role,
+    invited_by = EXCLUDED.invited_by,
+    token_hash = EXCLUDED.token_hash,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW(),
+    updated_at = NOW()
+WHERE workspace_invitations.expires_at <= NOW()
+RETURNING *;
+
+-- name: GetWorkspaceInvitation :one
+SELECT *
+FROM workspace_invitations
+WHERE id = $1
+  AND workspace_id = $2
+LIMIT 1;
+
+-- name: GetPendingWorkspaceInvitation :one
+SELECT *
+FROM workspace_invitations
+WHERE token_hash = $1
+  AND status = 'pending'
+  AND expires_at > NOW()
+  AND EXISTS (
+    SELECT 1 FROM workspaces w WHERE w.id = workspace_id AND w.deleted_at IS NULL
+  )
+LIMIT 1;
+
+-- name: ListWorkspaceInvitations :many
+SELECT *
+FROM workspace_invitations
+WHERE workspace_id = sqlc.arg(workspace_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountWorkspaceInvitations :one
+SELECT COUNT(*)
+FROM workspace_invitations
+WHERE workspace_id = sqlc.arg(workspace_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text);
+
+-- name: RenewWorkspaceInvitation :one
+UPDATE workspace_invitations
+SET token_hash = $2,
+    expires_at = $3,
+    updated_at = NOW()
+WHERE id = $1
+  AND status = 'pending'
+RETURNING *;
+
+-- name: RevokeWorkspaceInvitation :execrows
+UPDATE workspace_invitations
+SET status = 'revoked',
+    updated_at = NOW()
+WHERE id = $1
+  AND workspace_id = $2
+  AND status = 'pending';
+
+-- name: AcceptWorkspaceInvitation :execrows
+UPDATE workspace_invitations
+SET status = 'accepted',
+    accepted_by = $2,
+    accepted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND status = 'pending'
+  AND expires_at > NOW();
@@ -16,11 +16,32 @@ WHERE workspace_id = $1
 LIMIT 1;
 
 -- name: ListWorkspaceMembers :many
-SELECT *
+SELECT wm.*, u.name, u.email, u.avatar_url
+FROM workspace_members wm
+JOIN users u ON u.id = wm.user_id
+WHERE wm.workspace_id = $1
+ORDER BY wm.joined_at ASC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWorkspaceMembers :one
+SELECT COUNT(*)
+FROM workspace_members
+WHERE workspace_id = $1;
+
+-- name: CountWorkspaceOwners :one
+SELECT COUNT(*)
 FROM workspace_members
 WHERE workspace_id = $1
-ORDER BY joined_at DESC
-LIMIT $2 OFFSET $3;
+  AND role = 'owner';
+
+-- name: GetNextWorkspaceOwner :one
+SELECT user_id
+FROM workspace_members
+WHERE workspace_id = $1
+  AND user_id <> $2
+  AND role = 'owner'
+ORDER BY joined_at ASC
+LIMIT 1;
 
 -- name: UpdateWorkspaceMemberRole :one
 UPDATE workspace_members
@@ -34,3 +55,6 @@ DELETE FROM workspace_members
 WHERE workspace_id = $1
   AND user_id = $2;
 
+-- name: RemoveUserFromAllWorkspaces :exec
+DELETE FROM workspace_members
+WHERE user_id = $1;
@@ -0,0 +1,25 @@
+-- name: AddWorkspaceSlugHistory :exec
+INSERT INTO workspace_slug_history (
+    slug,
+    workspace_id
+) VALUES (
+    $1, $2
+);
+
+-- name: GetWorkspaceIDByOldSlug :one
+SELECT workspace_id
+FROM workspace_slug_history
+WHERE slug = $1
+LIMIT 1;
+
+-- name: DeleteWorkspaceSlugHistory :exec
+DELETE FROM workspace_slug_history
+WHERE slug = $1
+  AND workspace_id = $2;
+
+-- name: WorkspaceSlugTaken :one
+SELECT EXISTS (
+    SELECT 1 FROM workspaces w WHERE w.slug = sqlc.arg(slug) AND w.id <> sqlc.arg(workspace_id)
+) OR EXISTS (
+    SELECT 1 FROM workspace_slug_history h WHERE h.slug = sqlc.arg(slug) AND h.workspace_id <> sqlc.arg(workspace_id)
+);
@@ -14,34 +14,119 @@ RETURNING *;
 SELECT *
 FROM workspaces
 WHERE id = $1
+  AND deleted_at IS NULL
 LIMIT 1;
 
+-- name: GetWorkspaceForUpdate :one
+SELECT *
+FROM workspaces
+WHERE id = $1
+  AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: SetWorkspaceOwner :exec
+UPDATE workspaces
+SET owner_id = $2, updated_at = NOW()
+WHERE id = $1;
+
 -- name: GetWorkspaceBySlug :one
 SELECT *
 FROM workspaces
 WHERE slug = $1
+  AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: ListWorkspacesByOwnerID :many
+-- Includes workspaces in the trash, which are still owned until purged.
 SELECT *
 FROM workspaces
 WHERE owner_id = $1
-ORDER BY created_at DESC
+ORDER BY created_at DESC, id
 LIMIT $2 OFFSET $3;
 
 -- name: UpdateWorkspace :one
+-- owner_id is left alone; only SetWorkspaceOwner, under the row lock taken
+-- by a transfer, changes it.
 UPDATE workspaces
 SET
     name = $2,
     slug = $3,
     description = $4,
-    owner_id = $5,
-    status = $6,
+    status = $5,
     updated_at = NOW()
 WHERE id = $1
+  AND deleted_at IS NULL
 RETURNING *;
 
--- name: DeleteWorkspace :exec
+-- name: TrashWorkspace :one
+UPDATE workspaces
+SET deleted_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetDeletedWorkspaceByID :one
+SELECT *
+FROM workspaces
+WHERE id = $1
+  AND deleted_at IS NOT NULL
+LIMIT 1;
+
+-- name: RestoreWorkspace :one
+UPDATE workspaces
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1
+  AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedWorkspaces :execrows
 DELETE FROM workspaces
-WHERE id = $1;
+WHERE deleted_at < sqlc.arg(deleted_before);
+
+
+-- name: ListWorkspacesForUser :many
+SELECT w.*, wm.role
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = sqlc.arg(user_id)
+  AND w.deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL OR w.status = sqlc.narg(status)::text)
+  AND (sqlc.narg(search)::text IS NULL OR w.name ILIKE '%' || sqlc.narg(search)::text || '%')
+ORDER BY
+    CASE WHEN sqlc.arg(sort)::text = 'name' THEN LOWER(w.name) END ASC,
+    CASE WHEN sqlc.arg(sort)::text = '-name' THEN LOWER(w.name) END DESC,
+    CASE WHEN sqlc.arg(sort)::text = 'created_at' THEN w.created_at END ASC,
+    CASE WHEN sqlc.arg(sort)::text = '-created_at' THEN w.created_at END DESC,
+    CASE WHEN sqlc.arg(sort)::text = 'updated_at' THEN w.updated_at END ASC,
+    CASE WHEN sqlc.arg(sort)::text = '-updated_at' THEN w.updated_at END DESC,
+    w.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountWorkspacesForUser :one
+SELECT COUNT(*)
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = sqlc.arg(user_id)
+  AND w.deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL OR w.status = sqlc.narg(status)::text)
+  AND (sqlc.narg(search)::text IS NULL OR w.name ILIKE '%' || sqlc.narg(search)::text || '%');
+
+-- name: ListDeletedWorkspacesForUser :many
+-- Only memberships whose role is in roles are listed, so the trash shows
+-- just the workspaces the user could restore.
+SELECT w.*, wm.role
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = sqlc.arg(user_id)
+  AND wm.role = ANY(sqlc.arg(roles)::text[])
+  AND w.deleted_at IS NOT NULL
+ORDER BY w.deleted_at DESC, w.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
 
+-- name: CountDeletedWorkspacesForUser :one
+SELECT COUNT(*)
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.id
+WHERE wm.user_id = sqlc.arg(user_id)
+  AND wm.role = ANY(sqlc.arg(roles)::text[])
+  AND w.deleted_at IS NOT NULL;
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/password"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+// Global roles stored in users.role.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// PasswordResetter sends a user the password reset email. The auth handler
+// implements it.
+type PasswordResetter interface {
+	SendPasswordReset(ctx context.Context, user db.User) error
+}
+
+type Handler struct {
+	pool      *pgxpool.Pool
+	queries   *db.Queries
+	limiter   *throttle.Limiter
+	passwords *password.Service
+	resetter  PasswordResetter
+}
+
+func NewHandler(pool *pgxpool.Pool, limiter *throttle.Limiter, passwords *password.Service, resetter PasswordResetter) *Handler {
+	return &Handler{pool: pool, queries: db.New(pool), limiter: limiter, passwords: passwords, resetter: resetter}
+}
+
+// UnlockUser lifts a lockout caused by repeated failed logins and clears the
+// account's login throttles.
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	adminID, err := currentUserID(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	unlocked, err := h.queries.UnlockUser(r.Context(), user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to unlock user")
+		return
+	}
+	if unlocked == 0 {
+		utils.Error(w, http.StatusConflict, "user is not locked")
+		return
+	}
+
+	if err := h.limiter.Reset(r.Context(),
+		throttle.AccountKey(throttle.ActionLogin, user.Email),
+		throttle.AccountKey(throttle.ActionMFA, user.Email),
+	); err != nil {
+		slog.Error("failed to reset login throttles", "user_id", user.ID, "error", err)
+	}
+
+	h.logAction(r.Context(), adminID, "admin.user_unlocked", user.ID, nil)
+
+	utils.JSON(w, http.StatusOK, map[string]string{"message": "user unlocked"})
+}
+
+// logAction records an admin action against a user in activity_logs.
+func (h *Handler) logAction(ctx context.Context, adminID uuid.UUID, action string, userID uuid.UUID, metadata map[string]any) {
+	var raw []byte
+	if metadata != nil {
+		raw, _ = json.Marshal(metadata)
+	}
+
+	if _, err := h.queries.CreateActivityLog(ctx, db.CreateActivityLogParams{
+		UserID:     adminID,
+		Action:     action,
+		EntityType: "user",
+		EntityID:   userID,
+		Metadata:   raw,
+	}); err != nil {
+		slog.Error("failed to record admin action", "action", action, "user_id", userID, "error", err)
+	}
+}
+
+func currentUserID(r *http.Request) (uuid.UUID, error) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		return uuid.Nil, errors.New("unauthorized")
+	}
+	return uuid.Parse(userID)
+}
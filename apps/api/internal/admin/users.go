@@ -0,0 +1,345 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+// The user list pages larger than the workspace-scoped lists.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+type UserResponse struct {
+	ID               uuid.UUID  `json:"id"`
+	Name             string     `json:"name"`
+	Email            string     `json:"email"`
+	IsVerified       bool       `json:"is_verified"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	Status           string     `json:"status"`
+	Role             string     `json:"role"`
+	LockedUntil      *time.Time `json:"locked_until"`
+	LastLoginAt      *time.Time `json:"last_login_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type PaginatedUsersResponse struct {
+	Users  []UserResponse `json:"users"`
+	Total  int64          `json:"total"`
+	Limit  int32          `json:"limit"`
+	Offset int32          `json:"offset"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func toUserResponse(user db.User) UserResponse {
+	return UserResponse{
+		ID:               user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		IsVerified:       user.IsVerified,
+		TwoFactorEnabled: user.TotpEnabled,
+		Status:           user.Status,
+		Role:             user.Role,
+		LockedUntil:      timePtr(user.LockedUntil),
+		LastLoginAt:      timePtr(user.LastLoginAt),
+		CreatedAt:        user.CreatedAt.Time,
+		UpdatedAt:        user.UpdatedAt.Time,
+	}
+}
+
+// ListUsers returns users newest first. The q parameter searches names and
+// emails; status and role filter exactly.
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, offset := utils.GetPagination(r, defaultPageLimit, maxPageLimit)
+
+	search := optionalText(utils.LikePattern(strings.TrimSpace(query.Get("q"))))
+	status := optionalText(query.Get("status"))
+	role := optionalText(query.Get("role"))
+
+	users, err := h.queries.ListUsers(r.Context(), db.ListUsersParams{
+		Search:    search,
+		Status:    status,
+		Role:      role,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+
+	total, err := h.queries.CountUsers(r.Context(), db.CountUsersParams{
+		Search: search,
+		Status: status,
+		Role:   role,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to count users")
+		return
+	}
+
+	resp := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, toUserResponse(user))
+	}
+
+	utils.JSON(w, http.StatusOK, PaginatedUsersResponse{
+		Users:  resp,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.userFromURL(w, r)
+	if !ok {
+		return
+	}
+	utils.JSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// SuspendUser blocks an account from signing in and ends its sessions. It
+// stays suspended until reactivated.
+func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	adminID, err := currentUserID(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, ok := h.userFromURL(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == adminID {
+		utils.Error(w, http.StatusBadRequest, "cannot suspend your own account")
+		return
+	}
+
+	suspended, err := h.queries.SuspendUser(r.Context(), user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to suspend user")
+		return
+	}
+	if suspended == 0 {
+		utils.Error(w, http.StatusConflict, "user cannot be suspended")
+		return
+	}
+
+	if err := h.queries.DeleteRefreshTokensByUserID(r.Context(), user.ID); err != nil {
+		slog.Error("failed to revoke sessions of suspended user", "user_id", user.ID, "error", err)
+	}
+
+	h.logAction(r.Context(), adminID, "admin.user_suspended", user.ID, nil)
+
+	utils.JSON(w, http.StatusOK, map[string]string{"message": "user suspended"})
+}
+
+func (h *Handler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	adminID, err := currentUserID(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, ok := h.userFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	reactivated, err := h.queries.ReactivateUser(r.Context(), user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to reactivate user")
+		return
+	}
+	if reactivated == 0 {
+		utils.Error(w, http.StatusConflict, "user is not suspended")
+		return
+	}
+
+	h.logAction(r.Context(), adminID, "admin.user_reactivated", user.ID, nil)
+
+	utils.JSON(w, http.StatusOK, map[string]string{"message": "user reactivated"})
+}
+
+// ForcePasswordReset replaces the user's password with one nobody knows,
+// ends their sessions, deletes their personal access tokens and emails them
+// a reset link.
+func (h *Handler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	adminID, err := currentUserID(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, ok := h.userFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	passwordHash, err := h.passwords.Unusable()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if _, err := q.UpdateUserPassword(r.Context(), db.UpdateUserPasswordParams{
+			ID:           user.ID,
+			PasswordHash: passwordHash,
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteRefreshTokensByUserID(r.Context(), user.ID); err != nil {
+			return err
+		}
+		return q.DeletePersonalAccessTokensByUserID(r.Context(), user.ID)
+	})
+	if err != nil {
+		slog.Error("failed to force password reset", "user_id", user.ID, "error", err)
+		utils.Error(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+
+	emailSent := true
+	if err := h.resetter.SendPasswordReset(r.Context(), user); err != nil {
+		slog.Error("failed to send forced password reset email", "user_id", user.ID, "error", err)
+		emailSent = false
+	}
+
+	h.logAction(r.Context(), adminID, "admin.password_reset_forced", user.ID, map[string]any{
+		"email_sent": emailSent,
+	})
+
+	utils.JSON(w, http.StatusOK, map[string]any{
+		"message":    "password reset",
+		"email_sent": emailSent,
+	})
+}
+
+// RevokeSessions signs the user out everywhere: refresh tokens are deleted and
+// access tokens already issued stop working.
+func (h *Handler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	adminID, err := currentUserID(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, ok := h.userFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if err := q.DeleteRefreshTokensByUserID(r.Context(), user.ID); err != nil {
+			return err
+		}
+		return q.IncrementUserTokenVersion(r.Context(), user.ID)
+	})
+	if err != nil {
+		slog.Error("failed to revoke sessions", "user_id", user.ID, "error", err)
+		utils.Error(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+
+	h.logAction(r.Context(), adminID, "admin.sessions_revoked", user.ID, nil)
+
+	utils.JSON(w, http.StatusOK, map[string]string{"message": "sessions revoked"})
+}
+
+// SetUserRole changes a user's global role. Admins cannot change their own
+// role, so there is always at least one admin left. The new role applies
+// from the user's next request, since it is read from the database rather
+// than the access token.
+func (h *Handler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	adminID, err := currentUserID(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req SetRoleRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Role != RoleUser && req.Role != RoleAdmin {
+		utils.Error(w, http.StatusBadRequest, "role must be user or admin")
+		return
+	}
+
+	user, ok := h.userFromURL(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == adminID {
+		utils.Error(w, http.StatusBadRequest, "cannot change your own role")
+		return
+	}
+	if user.Role == req.Role {
+		utils.JSON(w, http.StatusOK, toUserResponse(user))
+		return
+	}
+
+	updated, err := h.queries.SetUserRole(r.Context(), db.SetUserRoleParams{
+		ID:   user.ID,
+		Role: req.Role,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to update role")
+		return
+	}
+
+	h.logAction(r.Context(), adminID, "admin.user_role_changed", user.ID, map[string]any{
+		"from": user.Role,
+		"to":   updated.Role,
+	})
+
+	utils.JSON(w, http.StatusOK, toUserResponse(updated))
+}
+
+// userFromURL loads the user named by the {id} URL parameter, writing an
+// error response if there is none.
+func (h *Handler) userFromURL(w http.ResponseWriter, r *http.Request) (db.User, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid user id")
+		return db.User{}, false
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, "user not found")
+		return db.User{}, false
+	}
+	return user, true
+}
+
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+func timePtr(v pgtype.Timestamptz) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
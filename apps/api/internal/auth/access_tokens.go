@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/pat"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+)
+
+const (
+	maxAccessTokensPerUser = 50
+	maxAccessTokenName     = 100
+)
+
+type createAccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type accessTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// createdAccessTokenResponse includes the raw token, which is only ever
+// returned here.
+type createdAccessTokenResponse struct {
+	accessTokenResponse
+	Token string `json:"token"`
+}
+
+func (h *Handler) ListAccessTokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	tokens, err := h.queries.ListPersonalAccessTokensByUserID(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch access tokens")
+		return
+	}
+
+	resp := make([]accessTokenResponse, len(tokens))
+	for i, t := range tokens {
+		resp[i] = toAccessTokenResponse(t)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]accessTokenResponse{"tokens": resp})
+}
+
+func (h *Handler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req createAccessTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAccessTokenName {
+		writeError(w, http.StatusBadRequest, "name is required (max 100 chars)")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		writeError(w, http.StatusBadRequest, "at least one scope is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !pat.ValidScope(scope) {
+			writeError(w, http.StatusBadRequest, "unknown scope: "+scope)
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		writeError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	count, err := h.queries.CountPersonalAccessTokensByUserID(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create access token")
+		return
+	}
+	if count >= maxAccessTokensPerUser {
+		writeError(w, http.StatusConflict, "access token limit reached")
+		return
+	}
+
+	raw, prefix, err := pat.Generate()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create access token")
+		return
+	}
+
+	expiresAt := pgtype.Timestamptz{}
+	if req.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: req.ExpiresAt.UTC(), Valid: true}
+	}
+
+	created, err := h.queries.CreatePersonalAccessToken(r.Context(), db.CreatePersonalAccessTokenParams{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenPrefix: prefix,
+		TokenHash:   token.Hash(raw),
+		Scopes:      req.Scopes,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create access token")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, createdAccessTokenResponse{
+		accessTokenResponse: toAccessTokenResponse(created),
+		Token:               raw,
+	})
+}
+
+func (h *Handler) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	tokenID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid token id")
+		return
+	}
+
+	deleted, err := h.queries.DeletePersonalAccessToken(r.Context(), db.DeletePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke access token")
+		return
+	}
+	if deleted == 0 {
+		writeError(w, http.StatusNotFound, "access token not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "access token revoked"})
+}
+
+func toAccessTokenResponse(t db.PersonalAccessToken) accessTokenResponse {
+	return accessTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.TokenPrefix,
+		Scopes:     t.Scopes,
+		ExpiresAt:  timePtr(t.ExpiresAt),
+		LastUsedAt: timePtr(t.LastUsedAt),
+		CreatedAt:  timePtr(t.CreatedAt),
+	}
+}
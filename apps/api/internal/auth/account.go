@@ -0,0 +1,479 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+	"github.con/falasefemi2/taskflow/api/internal/ownership"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+)
+
+const (
+	emailChangeTTL = 24 * time.Hour
+	// deleteConfirmation must be sent back verbatim to delete an account.
+	deleteConfirmation = "DELETE"
+	// ownedWorkspacesPageSize is how many owned workspaces are read at a
+	// time when deleting an account.
+	ownedWorkspacesPageSize = 100
+)
+
+type updateProfileRequest struct {
+	Name      *string `json:"name"`
+	AvatarURL *string `json:"avatar_url"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type changeEmailRequest struct {
+	NewEmail string `json:"new_email"`
+	Password string `json:"password"`
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+type deleteAccountRequest struct {
+	Password     string     `json:"password"`
+	Confirmation string     `json:"confirmation"`
+	TransferTo   *uuid.UUID `json:"transfer_to"`
+}
+
+type ownedWorkspaceResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+type ownedWorkspacesErrorResponse struct {
+	Error      string                   `json:"error"`
+	Workspaces []ownedWorkspaceResponse `json:"workspaces"`
+}
+
+// UpdateMe changes the caller's name and avatar. Omitted fields are left as
+// they are; an empty avatar_url removes the avatar.
+func (h *Handler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req updateProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := user.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		if name == "" || len([]rune(name)) > maxUserNameLength {
+			writeError(w, http.StatusBadRequest, "name is required (max 100 chars)")
+			return
+		}
+	}
+
+	avatarURL := user.AvatarUrl
+	if req.AvatarURL != nil {
+		avatarURL = pgtype.Text{String: strings.TrimSpace(*req.AvatarURL), Valid: true}
+		if avatarURL.String == "" {
+			avatarURL = pgtype.Text{}
+		} else if !isHTTPURL(avatarURL.String) {
+			writeError(w, http.StatusBadRequest, "avatar_url must be an http(s) URL")
+			return
+		}
+	}
+
+	updated, err := h.queries.UpdateUserProfile(r.Context(), db.UpdateUserProfileParams{
+		ID:        user.ID,
+		Name:      name,
+		AvatarUrl: avatarURL,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update profile")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]userResponse{"user": toUserResponse(updated)})
+}
+
+// ChangePassword sets a new password after checking the current one. Every
+// session is signed out and every personal access token deleted, and the
+// caller gets a new session in the response.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req changePasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		writeError(w, http.StatusBadRequest, "current_password and new_password are required")
+		return
+	}
+
+	if !h.passwords.Matches(req.CurrentPassword, user.PasswordHash) {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+	if err := h.passwords.Validate(req.NewPassword, user.Email, user.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	passwordHash, err := h.passwords.Hash(req.NewPassword)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+
+	// The new password, the sign-out and the caller's new session commit
+	// together, so a failure part way never leaves the old sessions alive
+	// or the caller without one.
+	var (
+		updated      db.User
+		resp         authResponse
+		refreshToken string
+	)
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		var err error
+		updated, err = q.UpdateUserPassword(r.Context(), db.UpdateUserPasswordParams{
+			ID:           user.ID,
+			PasswordHash: passwordHash,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteRefreshTokensByUserID(r.Context(), user.ID); err != nil {
+			return err
+		}
+		if err := q.DeletePersonalAccessTokensByUserID(r.Context(), user.ID); err != nil {
+			return err
+		}
+
+		resp, refreshToken, err = h.createSession(r, q, updated, nil)
+		return err
+	})
+	if err != nil {
+		slog.Error("failed to change password", "user_id", user.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+	h.notifyPasswordChanged(r.Context(), updated)
+	h.setRefreshCookie(w, refreshToken)
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ChangeEmail starts an email change. The address only changes once the
+// link sent to the new address is followed; until then the old one stays.
+func (h *Handler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req changeEmailRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	newEmail := strings.ToLower(strings.TrimSpace(req.NewEmail))
+	if addr, err := mail.ParseAddress(newEmail); err != nil || addr.Address != newEmail {
+		writeError(w, http.StatusBadRequest, "a valid new_email is required")
+		return
+	}
+	if newEmail == user.Email {
+		writeError(w, http.StatusBadRequest, "new_email is the current email")
+		return
+	}
+
+	if !h.passwords.Matches(req.Password, user.PasswordHash) {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	if _, err := h.queries.GetUserByEmail(r.Context(), newEmail); err == nil {
+		writeError(w, http.StatusConflict, "email already in use")
+		return
+	}
+
+	rawToken, err := token.GenerateOpaque(32)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create confirmation token")
+		return
+	}
+
+	if err := h.queries.SetUserPendingEmail(r.Context(), db.SetUserPendingEmailParams{
+		ID:                        user.ID,
+		PendingEmail:              pgtype.Text{String: newEmail, Valid: true},
+		EmailChangeToken:          pgtype.Text{String: token.Hash(rawToken), Valid: true},
+		EmailChangeTokenExpiresAt: pgtype.Timestamptz{Time: time.Now().UTC().Add(emailChangeTTL), Valid: true},
+	}); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to start email change")
+		return
+	}
+
+	msg, err := mailer.Render(newEmail, mailer.TemplateEmailChange, mailer.EmailChangeData{
+		Name:       user.Name,
+		NewEmail:   newEmail,
+		ConfirmURL: h.appLink("/confirm-email-change", rawToken),
+		ExpiresIn:  "24 hours",
+	})
+	if err == nil {
+		err = h.mailer.Send(r.Context(), msg)
+	}
+	if err != nil {
+		slog.Error("failed to send email change confirmation", "user_id", user.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to send confirmation email")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "a confirmation link has been sent to the new address",
+	})
+}
+
+// ConfirmEmailChange completes an email change. Following the link proves
+// ownership of the new address, so it also counts as verified.
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req confirmEmailChangeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Token == "" {
+		writeError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	user, err := h.queries.GetUserByEmailChangeToken(r.Context(), pgtype.Text{
+		String: token.Hash(req.Token),
+		Valid:  true,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	if !user.EmailChangeTokenExpiresAt.Valid || user.EmailChangeTokenExpiresAt.Time.Before(time.Now().UTC()) {
+		writeError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+
+	updated, err := h.queries.ConfirmUserEmailChange(r.Context(), user.ID)
+	if database.IsUniqueViolation(err) {
+		writeError(w, http.StatusConflict, "email already in use")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to change email")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]userResponse{"user": toUserResponse(updated)})
+}
+
+// DeleteMe deletes the caller's account. Workspaces the caller owns must
+// first be handed over with transfer_to, naming a user who is already a
+// member of each of them. Owned workspaces in the trash cannot be handed
+// over, so they have to be restored or purged first. The transfers and the
+// deletion commit together.
+func (h *Handler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req deleteAccountRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Confirmation != deleteConfirmation {
+		writeError(w, http.StatusBadRequest, `confirmation must be "DELETE"`)
+		return
+	}
+	if !h.passwords.Matches(req.Password, user.PasswordHash) {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	owned, err := listOwnedWorkspaces(r.Context(), h.queries, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check owned workspaces")
+		return
+	}
+
+	var trashed []db.Workspace
+	for _, ws := range owned {
+		if ws.DeletedAt.Valid {
+			trashed = append(trashed, ws)
+		}
+	}
+	if len(trashed) > 0 {
+		writeJSON(w, http.StatusConflict, ownedWorkspacesErrorResponse{
+			Error:      "you own workspaces in the trash; restore and transfer them, or wait for them to be purged",
+			Workspaces: toOwnedWorkspaces(trashed),
+		})
+		return
+	}
+
+	if len(owned) > 0 {
+		if req.TransferTo == nil {
+			writeJSON(w, http.StatusConflict, ownedWorkspacesErrorResponse{
+				Error:      "you own workspaces; transfer them with transfer_to or delete them first",
+				Workspaces: toOwnedWorkspaces(owned),
+			})
+			return
+		}
+		if !h.checkTransferTarget(w, r, user.ID, *req.TransferTo, owned) {
+			return
+		}
+	}
+
+	var transfers []ownership.Transfer
+	err = database.WithPgxTx(r.Context(), h.pool, func(tx pgx.Tx) error {
+		q := db.New(tx)
+		for _, ws := range owned {
+			t, err := ownership.Move(r.Context(), q, ws.ID, user.ID, *req.TransferTo)
+			if err != nil {
+				return err
+			}
+			transfers = append(transfers, t)
+		}
+		return deleteUser(r.Context(), tx, q, user.ID)
+	})
+	if errors.Is(err, ownership.ErrNotOwner) {
+		writeError(w, http.StatusConflict, "workspace ownership changed; try again")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to delete account", "user_id", user.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to delete account")
+		return
+	}
+
+	for _, t := range transfers {
+		if err := ownership.NotifyAccountDeleted(r.Context(), h.queries, h.mailer, t, user.Name); err != nil {
+			slog.Error("failed to send ownership transfer email", "workspace_id", t.Workspace.ID, "error", err)
+		}
+	}
+
+	h.clearRefreshCookie(w)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "account deleted"})
+}
+
+// listOwnedWorkspaces returns every workspace ownerID owns, including those
+// in the trash, reading them a page at a time.
+func listOwnedWorkspaces(ctx context.Context, q *db.Queries, ownerID uuid.UUID) ([]db.Workspace, error) {
+	var owned []db.Workspace
+	for offset := int32(0); ; offset += ownedWorkspacesPageSize {
+		page, err := q.ListWorkspacesByOwnerID(ctx, db.ListWorkspacesByOwnerIDParams{
+			OwnerID: ownerID,
+			Limit:   ownedWorkspacesPageSize,
+			Offset:  offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		owned = append(owned, page...)
+		if len(page) < ownedWorkspacesPageSize {
+			return owned, nil
+		}
+	}
+}
+
+// checkTransferTarget reports whether every workspace in owned can be handed
+// to newOwnerID. It writes an error response and returns false if not.
+func (h *Handler) checkTransferTarget(w http.ResponseWriter, r *http.Request, ownerID, newOwnerID uuid.UUID, owned []db.Workspace) bool {
+	if newOwnerID == ownerID {
+		writeError(w, http.StatusBadRequest, "transfer_to must be another user")
+		return false
+	}
+	if _, err := h.queries.GetUserByID(r.Context(), newOwnerID); err != nil {
+		writeError(w, http.StatusBadRequest, "transfer_to user not found")
+		return false
+	}
+
+	var notMember []db.Workspace
+	for _, ws := range owned {
+		if _, err := h.queries.GetWorkspaceMember(r.Context(), db.GetWorkspaceMemberParams{
+			WorkspaceID: ws.ID,
+			UserID:      newOwnerID,
+		}); err != nil {
+			notMember = append(notMember, ws)
+		}
+	}
+	if len(notMember) > 0 {
+		writeJSON(w, http.StatusConflict, ownedWorkspacesErrorResponse{
+			Error:      "transfer_to must be a member of every workspace you own",
+			Workspaces: toOwnedWorkspaces(notMember),
+		})
+		return false
+	}
+	return true
+}
+
+// deleteUser removes the user row. Users who authored projects, tasks or
+// activity are still referenced from those rows, so instead their personal
+// data is scrubbed and every way of signing in is removed. The delete is
+// tried under a savepoint so a foreign key violation does not abort tx.
+func deleteUser(ctx context.Context, tx pgx.Tx, q *db.Queries, userID uuid.UUID) error {
+	err := database.Savepoint(ctx, tx, func(q *db.Queries) error {
+		return q.DeleteUser(ctx, userID)
+	})
+	if err == nil || !database.IsForeignKeyViolation(err) {
+		return err
+	}
+
+	if err := q.AnonymizeUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := q.DeleteRefreshTokensByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if err := q.DeletePersonalAccessTokensByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if err := q.DeleteUserIdentitiesByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if err := q.DeleteRecoveryCodesByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if err := q.DeleteUserTokensByUserID(ctx, userID); err != nil {
+		return err
+	}
+	return q.RemoveUserFromAllWorkspaces(ctx, userID)
+}
+
+func toOwnedWorkspaces(workspaces []db.Workspace) []ownedWorkspaceResponse {
+	resp := make([]ownedWorkspaceResponse, len(workspaces))
+	for i, ws := range workspaces {
+		resp[i] = ownedWorkspaceResponse{ID: ws.ID, Name: ws.Name}
+	}
+	return resp
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -2,40 +2,62 @@ package auth
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	db "github.con/falasefemi2/taskflow/api/db/generated"
 	"github.con/falasefemi2/taskflow/api/internal/config"
-	"golang.org/x/crypto/bcrypt"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/oidc"
+	"github.con/falasefemi2/taskflow/api/internal/password"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
 )
 
 const (
-	accessTokenTTL   = 15 * time.Minute
-	refreshTokenTTL  = 7 * 24 * time.Hour
-	resetPasswordTTL = time.Hour
-	refreshCookieKey = "refresh_token"
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+	// refreshReuseGracePeriod tolerates a client racing two refreshes with
+	// the same token before treating a rotated token as stolen.
+	refreshReuseGracePeriod = 10 * time.Second
+	resetPasswordTTL        = time.Hour
+	magicLinkTTL            = 15 * time.Minute
+	verificationTTL         = 24 * time.Hour
+	mfaPendingTTL           = 5 * time.Minute
+	refreshCookieKey        = "refresh_token"
 )
 
+// errTokenAlreadyRotated means another request rotated a refresh token
+// between our read and update.
+var errTokenAlreadyRotated = errors.New("refresh token already rotated")
+
 type Handler struct {
-	queries *db.Queries
-	cfg     *config.Config
+	pool      *pgxpool.Pool
+	queries   *db.Queries
+	cfg       *config.Config
+	mailer    mailer.Mailer
+	tokens    *token.Service
+	limiter   *throttle.Limiter
+	providers *oidc.Registry
+	passwords *password.Service
 }
 
-func NewHandler(queries *db.Queries, cfg *config.Config) *Handler {
-	return &Handler{queries: queries, cfg: cfg}
+func NewHandler(pool *pgxpool.Pool, cfg *config.Config, mail mailer.Mailer, tokens *token.Service, limiter *throttle.Limiter, providers *oidc.Registry, passwords *password.Service) *Handler {
+	return &Handler{pool: pool, queries: db.New(pool), cfg: cfg, mailer: mail, tokens: tokens, limiter: limiter, providers: providers, passwords: passwords}
 }
 
 type registerRequest struct {
@@ -58,21 +80,37 @@ type resetPasswordRequest struct {
 	NewPassword string `json:"new_password"`
 }
 
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
 type authResponse struct {
 	AccessToken string       `json:"access_token"`
 	User        userResponse `json:"user"`
 }
 
+type mfaRequiredResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
 type userResponse struct {
-	ID         uuid.UUID  `json:"id"`
-	Name       string     `json:"name"`
-	Email      string     `json:"email"`
-	AvatarURL  *string    `json:"avatar_url"`
-	IsVerified bool       `json:"is_verified"`
-	Status     string     `json:"status"`
-	LastLogin  *time.Time `json:"last_login_at"`
-	CreatedAt  *time.Time `json:"created_at"`
-	UpdatedAt  *time.Time `json:"updated_at"`
+	ID               uuid.UUID  `json:"id"`
+	Name             string     `json:"name"`
+	Email            string     `json:"email"`
+	PendingEmail     *string    `json:"pending_email"`
+	AvatarURL        *string    `json:"avatar_url"`
+	IsVerified       bool       `json:"is_verified"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	Status           string     `json:"status"`
+	Role             string     `json:"role"`
+	LastLogin        *time.Time `json:"last_login_at"`
+	CreatedAt        *time.Time `json:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at"`
 }
 
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
@@ -81,8 +119,12 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	if req.Name == "" || req.Email == "" || len(req.Password) < 8 {
-		writeError(w, http.StatusBadRequest, "name, email and password(min 8 chars) are required")
+	if req.Name == "" || req.Email == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "name, email and password are required")
+		return
+	}
+	if err := h.passwords.Validate(req.Password, req.Email, req.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -96,7 +138,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	passwordHash, err := h.passwords.Hash(req.Password)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to hash password")
 		return
@@ -105,7 +147,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	user, err := h.queries.CreateUser(r.Context(), db.CreateUserParams{
 		Name:         req.Name,
 		Email:        strings.ToLower(req.Email),
-		PasswordHash: string(passwordHash),
+		PasswordHash: passwordHash,
 		AvatarUrl:    pgtype.Text{},
 	})
 	if err != nil {
@@ -113,7 +155,11 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, refreshToken, err := h.issueSession(r.Context(), user)
+	if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+		slog.Error("failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
+	resp, refreshToken, err := h.issueSession(r, user, nil)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create session")
 		return
@@ -134,22 +180,91 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.queries.GetUserByEmail(r.Context(), strings.ToLower(req.Email))
+	email := strings.ToLower(req.Email)
+	ipKey := throttle.IPKey(throttle.ActionLogin, utils.ClientIP(r))
+	accountKey := throttle.AccountKey(throttle.ActionLogin, email)
+	if h.throttled(w, r, ipKey, accountKey) {
+		return
+	}
+
+	user, err := h.queries.GetUserByEmail(r.Context(), email)
 	if err != nil {
+		h.recordLoginFailure(r.Context(), nil, ipKey, accountKey)
 		writeError(w, http.StatusUnauthorized, "invalid credentials")
 		return
 	}
+	if h.checkAccountLock(w, r, &user) {
+		return
+	}
+	if accountDisabled(user) {
+		writeError(w, http.StatusForbidden, "account suspended")
+		return
+	}
 
-	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+	needsRehash, err := h.passwords.Verify(req.Password, user.PasswordHash)
+	if err != nil {
+		h.recordLoginFailure(r.Context(), &user, ipKey, accountKey)
 		writeError(w, http.StatusUnauthorized, "invalid credentials")
 		return
 	}
+	h.resetAttempts(r.Context(), accountKey)
+	if needsRehash {
+		h.upgradePasswordHash(r.Context(), user, req.Password)
+	}
+
+	if user.TotpEnabled {
+		h.writeMFARequired(w, user)
+		return
+	}
 
+	h.completeLogin(w, r, user)
+}
+
+// writeMFARequired answers a login whose first factor succeeded with a
+// short-lived token for completing the second factor at /login/mfa.
+func (h *Handler) writeMFARequired(w http.ResponseWriter, user db.User) {
+	mfaToken, err := h.tokens.Issue(token.Claims{
+		Type:             token.TypeMFAPending,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: user.ID.String()},
+	}, mfaPendingTTL)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create session")
+		return
+	}
+	writeJSON(w, http.StatusOK, mfaRequiredResponse{MFARequired: true, MFAToken: mfaToken})
+}
+
+// upgradePasswordHash stores a fresh hash of a password that was just
+// verified against an outdated one. Failing to upgrade does not fail the
+// login; it is retried on the next one.
+func (h *Handler) upgradePasswordHash(ctx context.Context, user db.User, plain string) {
+	newHash, err := h.passwords.Hash(plain)
+	if err == nil {
+		err = h.queries.UpgradeUserPasswordHash(ctx, db.UpgradeUserPasswordHashParams{
+			ID:      user.ID,
+			NewHash: newHash,
+			OldHash: user.PasswordHash,
+		})
+	}
+	if err != nil {
+		slog.Error("failed to upgrade password hash", "user_id", user.ID, "error", err)
+	}
+}
+
+// accountDisabled reports whether user may not sign in at all. Unlike a
+// lockout, this only changes when an admin acts.
+func accountDisabled(user db.User) bool {
+	return user.Status == "suspended" || user.Status == "deleted"
+}
+
+// completeLogin records the login and starts a session once every required
+// factor has been checked.
+func (h *Handler) completeLogin(w http.ResponseWriter, r *http.Request, user db.User) {
 	if err := h.queries.UpdateUserLastLogin(r.Context(), user.ID); err == nil {
 		user.LastLoginAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
 	}
 
-	resp, refreshToken, err := h.issueSession(r.Context(), user)
+	resp, refreshToken, err := h.issueSession(r, user, nil)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create session")
 		return
@@ -166,12 +281,21 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenHash := hashToken(rawRefreshToken)
+	tokenHash := token.Hash(rawRefreshToken)
 	session, err := h.queries.GetRefreshTokenByHash(r.Context(), tokenHash)
 	if err != nil {
 		writeError(w, http.StatusUnauthorized, "invalid refresh token")
 		return
 	}
+	if session.RevokedAt.Valid {
+		h.clearRefreshCookie(w)
+		writeError(w, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+	if session.RotatedAt.Valid {
+		h.handleRefreshReuse(w, r, session)
+		return
+	}
 	if !session.ExpiresAt.Valid || session.ExpiresAt.Time.Before(time.Now().UTC()) {
 		_ = h.queries.DeleteRefreshTokenByHash(r.Context(), tokenHash)
 		h.clearRefreshCookie(w)
@@ -184,10 +308,34 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusUnauthorized, "user not found")
 		return
 	}
-
-	_ = h.queries.DeleteRefreshTokenByHash(r.Context(), tokenHash)
-
-	resp, newRefreshToken, err := h.issueSession(r.Context(), user)
+	if accountDisabled(user) {
+		h.clearRefreshCookie(w)
+		writeError(w, http.StatusForbidden, "account suspended")
+		return
+	}
+
+	// Rotating the old token and storing its successor commit together, so a
+	// failure in between cannot leave the client without a usable token. A
+	// concurrent refresh blocks on the row and then finds it already rotated.
+	var (
+		resp            authResponse
+		newRefreshToken string
+	)
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		rotated, err := q.RotateRefreshToken(r.Context(), session.ID)
+		if err != nil {
+			return err
+		}
+		if rotated == 0 {
+			return errTokenAlreadyRotated
+		}
+		resp, newRefreshToken, err = h.createSession(r, q, user, &session)
+		return err
+	})
+	if errors.Is(err, errTokenAlreadyRotated) {
+		writeError(w, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to refresh session")
 		return
@@ -197,10 +345,46 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// handleRefreshReuse responds to a refresh token that was already rotated.
+// Outside a short grace window for concurrent refreshes from the same client,
+// this means the token was copied, so the whole family is revoked.
+func (h *Handler) handleRefreshReuse(w http.ResponseWriter, r *http.Request, session db.RefreshToken) {
+	h.clearRefreshCookie(w)
+
+	if time.Since(session.RotatedAt.Time) < refreshReuseGracePeriod {
+		writeError(w, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+
+	if _, err := h.queries.RevokeRefreshTokenFamily(r.Context(), session.FamilyID); err != nil {
+		slog.Error("failed to revoke refresh token family", "family_id", session.FamilyID, "error", err)
+	}
+
+	metadata, _ := json.Marshal(map[string]string{
+		"family_id":  session.FamilyID.String(),
+		"ip_address": utils.ClientIP(r),
+		"user_agent": truncate(r.UserAgent(), maxUserAgentLength),
+	})
+	if _, err := h.queries.CreateActivityLog(r.Context(), db.CreateActivityLogParams{
+		UserID:     session.UserID,
+		Action:     "auth.refresh_token_reused",
+		EntityType: "refresh_token",
+		EntityID:   session.ID,
+		Metadata:   metadata,
+	}); err != nil {
+		slog.Error("failed to record refresh token reuse", "user_id", session.UserID, "error", err)
+	}
+
+	slog.Warn("refresh token reuse detected", "user_id", session.UserID, "family_id", session.FamilyID)
+	writeError(w, http.StatusUnauthorized, "refresh token reuse detected")
+}
+
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	rawRefreshToken, err := h.getRefreshCookie(r)
 	if err == nil && rawRefreshToken != "" {
-		_ = h.queries.DeleteRefreshTokenByHash(r.Context(), hashToken(rawRefreshToken))
+		if session, err := h.queries.GetRefreshTokenByHash(r.Context(), token.Hash(rawRefreshToken)); err == nil {
+			_ = h.queries.DeleteRefreshTokenFamily(r.Context(), session.FamilyID)
+		}
 	}
 
 	h.clearRefreshCookie(w)
@@ -218,7 +402,16 @@ func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.queries.GetUserByEmail(r.Context(), strings.ToLower(req.Email))
+	email := strings.ToLower(req.Email)
+	ipKey := throttle.IPKey(throttle.ActionForgotPassword, utils.ClientIP(r))
+	accountKey := throttle.AccountKey(throttle.ActionForgotPassword, email)
+	if h.throttled(w, r, ipKey, accountKey) {
+		return
+	}
+	// There is no failure to detect here, so every request counts.
+	h.recordAttempts(r.Context(), ipKey, accountKey)
+
+	user, err := h.queries.GetUserByEmail(r.Context(), email)
 	if err != nil {
 		writeJSON(w, http.StatusOK, map[string]string{
 			"message": "if an account exists for this email, a reset link has been sent",
@@ -226,35 +419,32 @@ func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resetToken, err := h.generateJWT(user.ID, "reset", resetPasswordTTL)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to create reset token")
-		return
+	if err := h.SendPasswordReset(r.Context(), user); err != nil {
+		slog.Error("failed to send password reset email", "user_id", user.ID, "error", err)
 	}
 
-	err = h.queries.SetUserResetToken(r.Context(), db.SetUserResetTokenParams{
-		ID: user.ID,
-		ResetToken: pgtype.Text{
-			String: hashToken(resetToken),
-			Valid:  true,
-		},
-		ResetTokenExpiresAt: pgtype.Timestamptz{
-			Time:  time.Now().UTC().Add(resetPasswordTTL),
-			Valid: true,
-		},
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "if an account exists for this email, a reset link has been sent",
 	})
+}
+
+// SendPasswordReset issues a password reset token for user, replacing any
+// earlier one, and emails the reset link.
+func (h *Handler) SendPasswordReset(ctx context.Context, user db.User) error {
+	resetToken, err := h.issueUserToken(ctx, user.ID, purposePasswordReset, resetPasswordTTL)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to set reset token")
-		return
+		return err
 	}
 
-	resp := map[string]string{
-		"message": "if an account exists for this email, a reset link has been sent",
-	}
-	if h.cfg.Primary.Env != "production" {
-		resp["reset_token"] = resetToken
+	msg, err := mailer.Render(user.Email, mailer.TemplatePasswordReset, mailer.PasswordResetData{
+		Name:      user.Name,
+		ResetURL:  h.appLink("/reset-password", resetToken),
+		ExpiresIn: "1 hour",
+	})
+	if err != nil {
+		return err
 	}
-	writeJSON(w, http.StatusOK, resp)
+	return h.mailer.Send(ctx, msg)
 }
 
 func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
@@ -263,60 +453,155 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	if req.Token == "" || len(req.NewPassword) < 8 {
-		writeError(w, http.StatusBadRequest, "token and new_password(min 8 chars) are required")
+	if req.Token == "" || req.NewPassword == "" {
+		writeError(w, http.StatusBadRequest, "token and new_password are required")
 		return
 	}
 
-	userID, tokenType, err := h.parseJWT(req.Token)
-	if err != nil || tokenType != "reset" {
-		writeError(w, http.StatusBadRequest, "invalid or expired token")
+	ipKey := throttle.IPKey(throttle.ActionResetPassword, utils.ClientIP(r))
+	if h.throttled(w, r, ipKey) {
 		return
 	}
 
-	user, err := h.queries.GetUserByID(r.Context(), userID)
+	// Check the new password before using up the token, so a rejected
+	// password can be retried with the same link.
+	user, err := h.userForToken(r.Context(), req.Token, purposePasswordReset)
 	if err != nil {
+		h.recordAttempts(r.Context(), ipKey)
 		writeError(w, http.StatusBadRequest, "invalid or expired token")
 		return
 	}
+	if err := h.passwords.Validate(req.NewPassword, user.Email, user.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	passwordHash, err := h.passwords.Hash(req.NewPassword)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
 
-	if !user.ResetToken.Valid || user.ResetToken.String != hashToken(req.Token) {
+	// Using up the token, setting the password and signing out every
+	// session and personal access token commit together; whoever asked for
+	// the reset may not be the only one holding a credential.
+	var updated db.User
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if _, err := q.ConsumeUserToken(r.Context(), db.ConsumeUserTokenParams{
+			TokenHash: token.Hash(req.Token),
+			Purpose:   purposePasswordReset,
+		}); errors.Is(err, pgx.ErrNoRows) {
+			return token.ErrInvalidToken
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = q.UpdateUserPassword(r.Context(), db.UpdateUserPasswordParams{
+			ID:           user.ID,
+			PasswordHash: passwordHash,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteRefreshTokensByUserID(r.Context(), user.ID); err != nil {
+			return err
+		}
+		return q.DeletePersonalAccessTokensByUserID(r.Context(), user.ID)
+	})
+	if errors.Is(err, token.ErrInvalidToken) {
 		writeError(w, http.StatusBadRequest, "invalid or expired token")
 		return
 	}
-	if !user.ResetTokenExpiresAt.Valid || user.ResetTokenExpiresAt.Time.Before(time.Now().UTC()) {
-		writeError(w, http.StatusBadRequest, "invalid or expired token")
+	if err != nil {
+		slog.Error("failed to reset password", "user_id", user.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to reset password")
 		return
 	}
+	h.notifyPasswordChanged(r.Context(), updated)
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "password reset successful"})
+}
+
+// notifyPasswordChanged tells the user their password changed, so they can
+// act if it was not them.
+func (h *Handler) notifyPasswordChanged(ctx context.Context, user db.User) {
+	msg, err := mailer.Render(user.Email, mailer.TemplatePasswordChanged, mailer.PasswordChangedData{
+		Name:     user.Name,
+		ResetURL: h.appURL("/forgot-password"),
+	})
+	if err == nil {
+		err = h.mailer.Send(ctx, msg)
+	}
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		slog.Error("failed to send password changed email", "user_id", user.ID, "error", err)
+	}
+}
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Token == "" {
+		writeError(w, http.StatusBadRequest, "token is required")
 		return
 	}
 
-	if err := h.queries.UpdateUserPassword(r.Context(), db.UpdateUserPasswordParams{
-		ID:           user.ID,
-		PasswordHash: string(passwordHash),
-	}); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to reset password")
+	user, err := h.queries.GetUserByVerificationToken(r.Context(), pgtype.Text{
+		String: token.Hash(req.Token),
+		Valid:  true,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	if !user.VerificationTokenExpiresAt.Valid || user.VerificationTokenExpiresAt.Time.Before(time.Now().UTC()) {
+		writeError(w, http.StatusBadRequest, "invalid or expired token")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"message": "password reset successful"})
+	if err := h.queries.MarkUserVerified(r.Context(), user.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify email")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "email verified"})
 }
 
-func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
-	authHeader := r.Header.Get("Authorization")
-	if !strings.HasPrefix(authHeader, "Bearer ") {
-		writeError(w, http.StatusUnauthorized, "missing bearer token")
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req resendVerificationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Email == "" {
+		writeError(w, http.StatusBadRequest, "email is required")
 		return
 	}
 
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-	userID, tokenType, err := h.parseJWT(token)
-	if err != nil || tokenType != "access" {
-		writeError(w, http.StatusUnauthorized, "invalid access token")
+	resp := map[string]string{
+		"message": "if an unverified account exists for this email, a verification link has been sent",
+	}
+
+	user, err := h.queries.GetUserByEmail(r.Context(), strings.ToLower(req.Email))
+	if err != nil || user.IsVerified {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to send verification email")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 
@@ -329,21 +614,62 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]userResponse{"user": toUserResponse(user)})
 }
 
-func (h *Handler) issueSession(ctx context.Context, user db.User) (authResponse, string, error) {
-	accessToken, err := h.generateJWT(user.ID, "access", accessTokenTTL)
+// currentUserID returns the user authenticated by the auth middleware.
+func (h *Handler) currentUserID(r *http.Request) (uuid.UUID, error) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		return uuid.Nil, errors.New("unauthorized")
+	}
+	return uuid.Parse(userID)
+}
+
+// issueSession creates an access token and a refresh token for user. A nil
+// parent starts a new refresh token family; otherwise the new refresh token
+// continues parent's family.
+func (h *Handler) issueSession(r *http.Request, user db.User, parent *db.RefreshToken) (authResponse, string, error) {
+	return h.createSession(r, h.queries, user, parent)
+}
+
+// createSession is issueSession storing the refresh token through q, so it
+// can be part of a larger transaction.
+func (h *Handler) createSession(r *http.Request, q *db.Queries, user db.User, parent *db.RefreshToken) (authResponse, string, error) {
+	ctx := r.Context()
+
+	familyID := uuid.New()
+	var parentID pgtype.UUID
+	if parent != nil {
+		familyID = parent.FamilyID
+		parentID = pgtype.UUID{Bytes: parent.ID, Valid: true}
+	}
+
+	accessToken, err := h.tokens.Issue(token.Claims{
+		Type:             token.TypeAccess,
+		Role:             user.Role,
+		SessionID:        familyID.String(),
+		Version:          user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: user.ID.String()},
+	}, accessTokenTTL)
 	if err != nil {
 		return authResponse{}, "", err
 	}
 
-	refreshToken, err := generateOpaqueToken(32)
+	refreshToken, err := token.GenerateOpaque(32)
 	if err != nil {
 		return authResponse{}, "", err
 	}
 
-	if _, err := h.queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
-		UserID:    user.ID,
-		TokenHash: hashToken(refreshToken),
-		ExpiresAt: pgtype.Timestamptz{Time: time.Now().UTC().Add(refreshTokenTTL), Valid: true},
+	now := time.Now().UTC()
+	userAgent := truncate(r.UserAgent(), maxUserAgentLength)
+	if _, err := q.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		UserID:      user.ID,
+		TokenHash:   token.Hash(refreshToken),
+		ExpiresAt:   pgtype.Timestamptz{Time: now.Add(refreshTokenTTL), Valid: true},
+		UserAgent:   pgtype.Text{String: userAgent, Valid: userAgent != ""},
+		IpAddress:   pgtype.Text{String: utils.ClientIP(r), Valid: true},
+		LastUsedAt:  pgtype.Timestamptz{Time: now, Valid: true},
+		DeviceLabel: pgtype.Text{String: deviceLabel(userAgent), Valid: true},
+		FamilyID:    familyID,
+		ParentID:    parentID,
 	}); err != nil {
 		return authResponse{}, "", err
 	}
@@ -354,6 +680,42 @@ func (h *Handler) issueSession(ctx context.Context, user db.User) (authResponse,
 	}, refreshToken, nil
 }
 
+// sendVerificationEmail issues a fresh verification token for user, replacing
+// any previous one, and mails the verification link.
+func (h *Handler) sendVerificationEmail(ctx context.Context, user db.User) error {
+	rawToken, err := token.GenerateOpaque(32)
+	if err != nil {
+		return err
+	}
+
+	if err := h.queries.SetUserVerificationToken(ctx, db.SetUserVerificationTokenParams{
+		ID:                         user.ID,
+		VerificationToken:          pgtype.Text{String: token.Hash(rawToken), Valid: true},
+		VerificationTokenExpiresAt: pgtype.Timestamptz{Time: time.Now().UTC().Add(verificationTTL), Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(user.Email, mailer.TemplateVerifyEmail, mailer.VerifyEmailData{
+		Name:      user.Name,
+		VerifyURL: h.appLink("/verify-email", rawToken),
+		ExpiresIn: "24 hours",
+	})
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(ctx, msg)
+}
+
+// appLink builds a frontend URL carrying a one-time token.
+func (h *Handler) appLink(path, rawToken string) string {
+	return h.appURL(path) + "?token=" + url.QueryEscape(rawToken)
+}
+
+func (h *Handler) appURL(path string) string {
+	return strings.TrimRight(h.cfg.Primary.AppURL, "/") + path
+}
+
 func (h *Handler) setRefreshCookie(w http.ResponseWriter, token string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     refreshCookieKey,
@@ -386,103 +748,20 @@ func (h *Handler) getRefreshCookie(r *http.Request) (string, error) {
 	return c.Value, nil
 }
 
-func (h *Handler) generateJWT(userID uuid.UUID, tokenType string, ttl time.Duration) (string, error) {
-	now := time.Now().UTC()
-	header := map[string]string{
-		"alg": "HS256",
-		"typ": "JWT",
-	}
-	payload := map[string]any{
-		"sub": userID.String(),
-		"typ": tokenType,
-		"iat": now.Unix(),
-		"exp": now.Add(ttl).Unix(),
-	}
-
-	headerBytes, err := json.Marshal(header)
-	if err != nil {
-		return "", err
-	}
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return "", err
-	}
-
-	encodedHeader := base64.RawURLEncoding.EncodeToString(headerBytes)
-	encodedPayload := base64.RawURLEncoding.EncodeToString(payloadBytes)
-	signingInput := encodedHeader + "." + encodedPayload
-
-	mac := hmac.New(sha256.New, []byte(h.cfg.Auth.JWTSecret))
-	_, _ = mac.Write([]byte(signingInput))
-	signature := mac.Sum(nil)
-
-	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
-}
-
-func (h *Handler) parseJWT(token string) (uuid.UUID, string, error) {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return uuid.Nil, "", errors.New("invalid token")
-	}
-
-	signingInput := parts[0] + "." + parts[1]
-	expectedMAC := hmac.New(sha256.New, []byte(h.cfg.Auth.JWTSecret))
-	_, _ = expectedMAC.Write([]byte(signingInput))
-	expectedSig := expectedMAC.Sum(nil)
-
-	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
-	if err != nil || !hmac.Equal(signature, expectedSig) {
-		return uuid.Nil, "", errors.New("invalid token")
-	}
-
-	var payload struct {
-		Sub string `json:"sub"`
-		Typ string `json:"typ"`
-		Exp int64  `json:"exp"`
-	}
-
-	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
-	if err != nil {
-		return uuid.Nil, "", err
-	}
-	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
-		return uuid.Nil, "", err
-	}
-
-	if payload.Exp < time.Now().UTC().Unix() {
-		return uuid.Nil, "", errors.New("token expired")
-	}
-	userID, err := uuid.Parse(payload.Sub)
-	if err != nil {
-		return uuid.Nil, "", err
-	}
-	return userID, payload.Typ, nil
-}
-
-func generateOpaqueToken(size int) (string, error) {
-	b := make([]byte, size)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
-	}
-	return base64.RawURLEncoding.EncodeToString(b), nil
-}
-
-func hashToken(token string) string {
-	sum := sha256.Sum256([]byte(token))
-	return hex.EncodeToString(sum[:])
-}
-
 func toUserResponse(user db.User) userResponse {
 	return userResponse{
-		ID:         user.ID,
-		Name:       user.Name,
-		Email:      user.Email,
-		AvatarURL:  textPtr(user.AvatarUrl),
-		IsVerified: user.IsVerified,
-		Status:     user.Status,
-		LastLogin:  timePtr(user.LastLoginAt),
-		CreatedAt:  timePtr(user.CreatedAt),
-		UpdatedAt:  timePtr(user.UpdatedAt),
+		ID:               user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		PendingEmail:     textPtr(user.PendingEmail),
+		AvatarURL:        textPtr(user.AvatarUrl),
+		IsVerified:       user.IsVerified,
+		TwoFactorEnabled: user.TotpEnabled,
+		Status:           user.Status,
+		Role:             user.Role,
+		LastLogin:        timePtr(user.LastLoginAt),
+		CreatedAt:        timePtr(user.CreatedAt),
+		UpdatedAt:        timePtr(user.UpdatedAt),
 	}
 }
 
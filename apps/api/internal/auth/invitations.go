@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+var (
+	// errInviteeExists means an account was created for the invited
+	// address after the invitation was checked.
+	errInviteeExists = errors.New("invitee already has an account")
+	// errInvitationGone means the invitation was accepted or revoked after
+	// it was read.
+	errInvitationGone = errors.New("invitation no longer pending")
+)
+
+type acceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type acceptInvitationResponse struct {
+	WorkspaceID uuid.UUID    `json:"workspace_id"`
+	Role        string       `json:"role"`
+	AccessToken string       `json:"access_token,omitempty"`
+	User        userResponse `json:"user"`
+}
+
+// AcceptInvitation joins the workspace an invitation was sent for. A signed
+// in user joins as themselves, and only if the invitation was sent to their
+// email address; holding the link is not enough. Anyone else sends a name and password to
+// create an account for the invited address; the emailed link proves they
+// own it, so the account starts out verified. The account, the membership
+// and the invitation's acceptance commit together.
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req acceptInvitationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Token == "" {
+		writeError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	ipKey := throttle.IPKey(throttle.ActionInvitationAccept, utils.ClientIP(r))
+	if h.throttled(w, r, ipKey) {
+		return
+	}
+
+	inv, err := h.queries.GetPendingWorkspaceInvitation(r.Context(), token.Hash(req.Token))
+	if err != nil {
+		h.recordAttempts(r.Context(), ipKey)
+		writeError(w, http.StatusBadRequest, "invalid or expired invitation")
+		return
+	}
+
+	var (
+		user         db.User
+		passwordHash string
+		ok           bool
+		registered   bool
+	)
+	if _, signedIn := middleware.GetUserID(r); signedIn {
+		user, ok = h.currentUser(w, r)
+		if ok && !strings.EqualFold(user.Email, inv.Email) {
+			writeError(w, http.StatusForbidden, "this invitation was sent to a different email address")
+			return
+		}
+	} else {
+		passwordHash, ok = h.checkInvitee(w, r, inv, req)
+		registered = true
+	}
+	if !ok {
+		return
+	}
+
+	var (
+		role         string
+		session      authResponse
+		refreshToken string
+	)
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if registered {
+			created, err := q.CreateUser(r.Context(), db.CreateUserParams{
+				Name:         req.Name,
+				Email:        inv.Email,
+				PasswordHash: passwordHash,
+				AvatarUrl:    pgtype.Text{},
+			})
+			if database.IsUniqueViolation(err) {
+				return errInviteeExists
+			}
+			if err != nil {
+				return err
+			}
+			user = created
+		}
+
+		if !user.IsVerified {
+			if err := q.MarkUserVerified(r.Context(), user.ID); err != nil {
+				return err
+			}
+			user.IsVerified = true
+		}
+
+		accepted, err := q.AcceptWorkspaceInvitation(r.Context(), db.AcceptWorkspaceInvitationParams{
+			ID:         inv.ID,
+			AcceptedBy: pgtype.UUID{Bytes: user.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if accepted == 0 {
+			return errInvitationGone
+		}
+
+		if role, err = joinWorkspace(r.Context(), q, inv, user.ID); err != nil {
+			return err
+		}
+
+		if registered {
+			session, refreshToken, err = h.createSession(r, q, user, nil)
+		}
+		return err
+	})
+	switch {
+	case errors.Is(err, errInviteeExists):
+		writeError(w, http.StatusConflict, "an account with this email already exists; sign in to accept the invitation")
+		return
+	case errors.Is(err, errInvitationGone):
+		writeError(w, http.StatusBadRequest, "invalid or expired invitation")
+		return
+	case err != nil:
+		slog.Error("failed to accept invitation", "invitation_id", inv.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to join workspace")
+		return
+	}
+
+	resp := acceptInvitationResponse{
+		WorkspaceID: inv.WorkspaceID,
+		Role:        role,
+		User:        toUserResponse(user),
+	}
+	if !registered {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	h.setRefreshCookie(w, refreshToken)
+	resp.AccessToken = session.AccessToken
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// joinWorkspace adds userID to the invitation's workspace and returns the
+// role they end up with. Someone who is already a member, perhaps through
+// another invitation, keeps the role they have rather than having it
+// silently changed.
+func joinWorkspace(ctx context.Context, q *db.Queries, inv db.WorkspaceInvitation, userID uuid.UUID) (string, error) {
+	member, err := q.GetWorkspaceMember(ctx, db.GetWorkspaceMemberParams{
+		WorkspaceID: inv.WorkspaceID,
+		UserID:      userID,
+	})
+	if err == nil {
+		return member.Role, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+
+	member, err = q.AddWorkspaceMember(ctx, db.AddWorkspaceMemberParams{
+		WorkspaceID: inv.WorkspaceID,
+		UserID:      userID,
+		Role:        inv.Role,
+	})
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// checkInvitee validates the details for creating an account for the address
+// inv was sent to and returns the password hash to create it with. It
+// writes an error response and returns false if that is not possible.
+func (h *Handler) checkInvitee(w http.ResponseWriter, r *http.Request, inv db.WorkspaceInvitation, req acceptInvitationRequest) (string, bool) {
+	if req.Name == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "name and password are required to create an account")
+		return "", false
+	}
+	if err := h.passwords.Validate(req.Password, inv.Email, req.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+
+	if _, err := h.queries.GetUserByEmail(r.Context(), inv.Email); err == nil {
+		writeError(w, http.StatusConflict, "an account with this email already exists; sign in to accept the invitation")
+		return "", false
+	}
+
+	passwordHash, err := h.passwords.Hash(req.Password)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		return "", false
+	}
+	return passwordHash, true
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+type magicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type consumeMagicLinkRequest struct {
+	Token string `json:"token"`
+}
+
+// RequestMagicLink emails a single-use sign-in link. Like ForgotPassword, it
+// answers the same way whether or not the account exists.
+func (h *Handler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req magicLinkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Email == "" {
+		writeError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	email := strings.ToLower(req.Email)
+	ipKey := throttle.IPKey(throttle.ActionMagicLink, utils.ClientIP(r))
+	accountKey := throttle.AccountKey(throttle.ActionMagicLink, email)
+	if h.throttled(w, r, ipKey, accountKey) {
+		return
+	}
+	h.recordAttempts(r.Context(), ipKey, accountKey)
+
+	user, err := h.queries.GetUserByEmail(r.Context(), email)
+	if err == nil && !accountDisabled(user) {
+		if err := h.sendMagicLink(r.Context(), user); err != nil {
+			slog.Error("failed to send magic link email", "user_id", user.ID, "error", err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "if an account exists for this email, a sign-in link has been sent",
+	})
+}
+
+// ConsumeMagicLink exchanges a magic link token for a session. Accounts with
+// two-factor authentication still have to complete the second factor.
+func (h *Handler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req consumeMagicLinkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Token == "" {
+		writeError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	ipKey := throttle.IPKey(throttle.ActionMagicLinkConsume, utils.ClientIP(r))
+	if h.throttled(w, r, ipKey) {
+		return
+	}
+
+	user, err := h.consumeUserToken(r.Context(), req.Token, purposeMagicLink)
+	if err != nil {
+		h.recordAttempts(r.Context(), ipKey)
+		writeError(w, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	if accountDisabled(user) {
+		writeError(w, http.StatusForbidden, "account suspended")
+		return
+	}
+	if h.checkAccountLock(w, r, &user) {
+		return
+	}
+
+	// Opening the link proves the user owns the address.
+	if err := h.claimUnverifiedUser(r.Context(), &user); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create session")
+		return
+	}
+
+	if user.TotpEnabled {
+		h.writeMFARequired(w, user)
+		return
+	}
+
+	h.completeLogin(w, r, user)
+}
+
+func (h *Handler) sendMagicLink(ctx context.Context, user db.User) error {
+	rawToken, err := h.issueUserToken(ctx, user.ID, purposeMagicLink, magicLinkTTL)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(user.Email, mailer.TemplateMagicLink, mailer.MagicLinkData{
+		Name:      user.Name,
+		LoginURL:  h.appLink("/login/magic-link", rawToken),
+		ExpiresIn: "15 minutes",
+	})
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(ctx, msg)
+}
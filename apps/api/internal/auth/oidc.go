@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/oidc"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+)
+
+const (
+	oidcStateCookieKey = "oidc_state"
+	oidcStateTTL       = 10 * time.Minute
+	oidcCookiePath     = "/api/v1/auth/oidc"
+	maxUserNameLength  = 100
+)
+
+var errIdentityEmailUnverified = errors.New("identity provider did not verify the email address")
+
+// OIDCLogin starts a sign-in with an external identity provider by
+// redirecting to its authorization endpoint. An optional redirect query
+// parameter names the app path to return to afterwards.
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider, err := h.providers.Get(chi.URLParam(r, "provider"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+
+	st := oidc.State{
+		Provider:  provider.Name(),
+		Redirect:  safeRedirectPath(r.URL.Query().Get("redirect")),
+		ExpiresAt: time.Now().Add(oidcStateTTL).Unix(),
+	}
+	for _, v := range []*string{&st.State, &st.Nonce, &st.Verifier} {
+		if *v, err = oidc.RandomString(); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to start login")
+			return
+		}
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), st.State, st.Nonce, oidc.Challenge(st.Verifier))
+	if err != nil {
+		slog.Error("failed to build oidc authorization url", "provider", provider.Name(), "error", err)
+		writeError(w, http.StatusBadGateway, "identity provider unavailable")
+		return
+	}
+
+	cookieValue, err := oidc.EncodeState(h.cfg.OIDC.StateSecret, st)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+	h.setOIDCStateCookie(w, cookieValue, int(oidcStateTTL.Seconds()))
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback completes a sign-in started by OIDCLogin. It always redirects
+// back to the app: to the requested path with a refresh cookie set on
+// success, or to the login page with an error code otherwise.
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider, err := h.providers.Get(chi.URLParam(r, "provider"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+
+	st, err := h.readOIDCState(r)
+	h.setOIDCStateCookie(w, "", -1)
+	q := r.URL.Query()
+	if err != nil || st.Provider != provider.Name() || st.State != q.Get("state") {
+		h.redirectOIDCError(w, r, "invalid_state")
+		return
+	}
+	if q.Get("error") != "" || q.Get("code") == "" {
+		h.redirectOIDCError(w, r, "provider_error")
+		return
+	}
+
+	tok, err := provider.Exchange(r.Context(), q.Get("code"), st.Verifier)
+	if err != nil {
+		slog.Error("oidc code exchange failed", "provider", provider.Name(), "error", err)
+		h.redirectOIDCError(w, r, "provider_error")
+		return
+	}
+	identity, err := provider.VerifyIDToken(r.Context(), tok.IDToken, st.Nonce)
+	if err != nil {
+		slog.Warn("oidc id token rejected", "provider", provider.Name(), "error", err)
+		h.redirectOIDCError(w, r, "invalid_token")
+		return
+	}
+
+	user, err := h.userForIdentity(r.Context(), provider.Name(), identity)
+	if errors.Is(err, errIdentityEmailUnverified) {
+		h.redirectOIDCError(w, r, "email_not_verified")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to resolve oidc identity", "provider", provider.Name(), "error", err)
+		h.redirectOIDCError(w, r, "server_error")
+		return
+	}
+
+	if accountDisabled(user) {
+		h.redirectOIDCError(w, r, "account_suspended")
+		return
+	}
+	if remaining, err := h.lockRemaining(r.Context(), &user); err != nil || remaining > 0 {
+		h.redirectOIDCError(w, r, "account_locked")
+		return
+	}
+
+	if user.TotpEnabled {
+		mfaToken, err := h.tokens.Issue(token.Claims{
+			Type:             token.TypeMFAPending,
+			RegisteredClaims: jwt.RegisteredClaims{Subject: user.ID.String()},
+		}, mfaPendingTTL)
+		if err != nil {
+			h.redirectOIDCError(w, r, "server_error")
+			return
+		}
+		// The fragment keeps the token out of server logs and Referer headers.
+		http.Redirect(w, r, h.appURL("/login/mfa")+"#"+url.Values{"mfa_token": {mfaToken}}.Encode(), http.StatusFound)
+		return
+	}
+
+	if err := h.queries.UpdateUserLastLogin(r.Context(), user.ID); err == nil {
+		user.LastLoginAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+	}
+	_, refreshToken, err := h.issueSession(r, user, nil)
+	if err != nil {
+		h.redirectOIDCError(w, r, "server_error")
+		return
+	}
+	h.setRefreshCookie(w, refreshToken)
+
+	http.Redirect(w, r, h.appURL(st.Redirect), http.StatusFound)
+}
+
+// userForIdentity returns the user linked to an external identity. An
+// unknown identity is linked to the account with the same email, or gets a
+// new account, but only if the provider has verified the email.
+func (h *Handler) userForIdentity(ctx context.Context, provider string, id *oidc.IDToken) (db.User, error) {
+	existing, err := h.queries.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  id.Subject,
+	})
+	if err == nil {
+		if err := h.queries.TouchUserIdentity(ctx, db.TouchUserIdentityParams{
+			ID:    existing.ID,
+			Email: pgtype.Text{String: id.Email, Valid: id.Email != ""},
+		}); err != nil {
+			slog.Error("failed to update user identity", "identity_id", existing.ID, "error", err)
+		}
+		return h.queries.GetUserByID(ctx, existing.UserID)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return db.User{}, err
+	}
+
+	if id.Email == "" || !id.EmailVerified {
+		return db.User{}, errIdentityEmailUnverified
+	}
+
+	user, err := h.queries.GetUserByEmail(ctx, id.Email)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		user, err = h.createUserForIdentity(ctx, id)
+	case err == nil:
+		err = h.claimUnverifiedUser(ctx, &user)
+	}
+	if err != nil {
+		return db.User{}, err
+	}
+
+	if _, err := h.queries.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  id.Subject,
+		Email:    pgtype.Text{String: id.Email, Valid: true},
+	}); err != nil {
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+func (h *Handler) createUserForIdentity(ctx context.Context, id *oidc.IDToken) (db.User, error) {
+	passwordHash, err := h.passwords.Unusable()
+	if err != nil {
+		return db.User{}, err
+	}
+
+	name := id.Name
+	if name == "" {
+		name, _, _ = strings.Cut(id.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > maxUserNameLength {
+		name = string(runes[:maxUserNameLength])
+	}
+
+	user, err := h.queries.CreateUser(ctx, db.CreateUserParams{
+		Name:         name,
+		Email:        id.Email,
+		PasswordHash: passwordHash,
+		AvatarUrl:    pgtype.Text{String: id.Picture, Valid: id.Picture != ""},
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+
+	if err := h.queries.MarkUserVerified(ctx, user.ID); err != nil {
+		return db.User{}, err
+	}
+	user.IsVerified = true
+	return user, nil
+}
+
+// claimUnverifiedUser prepares an existing account for linking. If its email
+// was never verified, whoever registered it may not own the address, so the
+// password and sessions they set up are discarded before the real owner,
+// vouched for by the identity provider, takes it over.
+func (h *Handler) claimUnverifiedUser(ctx context.Context, user *db.User) error {
+	if user.IsVerified {
+		return nil
+	}
+
+	passwordHash, err := h.passwords.Unusable()
+	if err != nil {
+		return err
+	}
+	updated, err := h.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return err
+	}
+	if err := h.queries.DeleteRefreshTokensByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := h.queries.MarkUserVerified(ctx, user.ID); err != nil {
+		return err
+	}
+
+	*user = updated
+	user.IsVerified = true
+	return nil
+}
+
+func (h *Handler) readOIDCState(r *http.Request) (oidc.State, error) {
+	c, err := r.Cookie(oidcStateCookieKey)
+	if err != nil {
+		return oidc.State{}, oidc.ErrInvalidState
+	}
+	return oidc.DecodeState(h.cfg.OIDC.StateSecret, c.Value)
+}
+
+func (h *Handler) setOIDCStateCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieKey,
+		Value:    value,
+		Path:     oidcCookiePath,
+		HttpOnly: true,
+		Secure:   h.cfg.Primary.Env == "production",
+		// Lax lets the cookie through on the provider's top-level redirect
+		// back to the callback.
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	})
+}
+
+func (h *Handler) redirectOIDCError(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, h.appURL("/login")+"?"+url.Values{"error": {code}}.Encode(), http.StatusFound)
+}
+
+// safeRedirectPath only allows paths within the app, so the login flow
+// cannot be used as an open redirect.
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, `\`) {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+)
+
+type preferencesRequest struct {
+	DefaultWorkspaceID *uuid.UUID `json:"default_workspace_id"`
+}
+
+type preferencesResponse struct {
+	DefaultWorkspaceID *uuid.UUID `json:"default_workspace_id"`
+}
+
+func toPreferencesResponse(user db.User) preferencesResponse {
+	var res preferencesResponse
+	if user.DefaultWorkspaceID.Valid {
+		id := uuid.UUID(user.DefaultWorkspaceID.Bytes)
+		res.DefaultWorkspaceID = &id
+	}
+	return res
+}
+
+// GetPreferences returns the caller's preferences.
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toPreferencesResponse(user))
+}
+
+// UpdatePreferences sets the caller's default workspace, which must be one
+// they belong to. A null default_workspace_id clears it.
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req preferencesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var defaultWorkspaceID pgtype.UUID
+	if req.DefaultWorkspaceID != nil {
+		if _, err := h.queries.GetWorkspaceMember(r.Context(), db.GetWorkspaceMemberParams{
+			WorkspaceID: *req.DefaultWorkspaceID,
+			UserID:      user.ID,
+		}); err != nil {
+			writeError(w, http.StatusBadRequest, "default_workspace_id must be a workspace you belong to")
+			return
+		}
+		defaultWorkspaceID = pgtype.UUID{Bytes: *req.DefaultWorkspaceID, Valid: true}
+	}
+
+	updated, err := h.queries.SetUserDefaultWorkspace(r.Context(), db.SetUserDefaultWorkspaceParams{
+		ID:                 user.ID,
+		DefaultWorkspaceID: defaultWorkspaceID,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update preferences")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toPreferencesResponse(updated))
+}
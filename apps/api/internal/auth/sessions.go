@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+)
+
+const (
+	maxSessionsListed  = 100
+	maxUserAgentLength = 512
+)
+
+// sessionResponse describes one refresh token family. Its ID is the family
+// ID, which stays stable across refresh token rotations.
+type sessionResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	DeviceLabel *string    `json:"device_label"`
+	UserAgent   *string    `json:"user_agent"`
+	IPAddress   *string    `json:"ip_address"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   *time.Time `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Current     bool       `json:"current"`
+}
+
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	tokens, err := h.queries.ListRefreshTokensByUserID(r.Context(), db.ListRefreshTokensByUserIDParams{
+		UserID: userID,
+		Limit:  maxSessionsListed,
+		Offset: 0,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch sessions")
+		return
+	}
+
+	currentHash := h.currentRefreshHash(r)
+	sessions := make([]sessionResponse, len(tokens))
+	for i, t := range tokens {
+		sessions[i] = toSessionResponse(t, t.TokenHash == currentHash)
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]sessionResponse{"sessions": sessions})
+}
+
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+
+	deleted, err := h.queries.DeleteRefreshTokenFamilyByUserID(r.Context(), db.DeleteRefreshTokenFamilyByUserIDParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+	if deleted == 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "session revoked"})
+}
+
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	current, err := h.queries.GetRefreshTokenByHash(r.Context(), h.currentRefreshHash(r))
+	if err != nil || current.UserID != userID {
+		writeError(w, http.StatusBadRequest, "current session not found")
+		return
+	}
+
+	revoked, err := h.queries.DeleteOtherRefreshTokensByUserID(r.Context(), db.DeleteOtherRefreshTokensByUserIDParams{
+		UserID:   userID,
+		FamilyID: current.FamilyID,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": revoked})
+}
+
+// currentRefreshHash returns the hash of the refresh cookie sent with r, or
+// an empty string when there is none.
+func (h *Handler) currentRefreshHash(r *http.Request) string {
+	raw, err := h.getRefreshCookie(r)
+	if err != nil {
+		return ""
+	}
+	return token.Hash(raw)
+}
+
+func toSessionResponse(t db.RefreshToken, current bool) sessionResponse {
+	return sessionResponse{
+		ID:          t.FamilyID,
+		DeviceLabel: textPtr(t.DeviceLabel),
+		UserAgent:   textPtr(t.UserAgent),
+		IPAddress:   textPtr(t.IpAddress),
+		LastUsedAt:  timePtr(t.LastUsedAt),
+		CreatedAt:   timePtr(t.CreatedAt),
+		ExpiresAt:   timePtr(t.ExpiresAt),
+		Current:     current,
+	}
+}
+
+// deviceLabel derives a short human-readable label such as "Firefox on
+// Windows" from a User-Agent header.
+func deviceLabel(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+)
+
+const (
+	// accountLockThreshold is the number of failed logins within the throttle
+	// window after which the account itself is locked.
+	accountLockThreshold = 10
+	accountLockDuration  = 30 * time.Minute
+)
+
+// throttled reports whether any of keys is currently delayed and, if so,
+// writes a 429 response. A failing throttle store is logged and lets the
+// request through rather than locking everybody out.
+func (h *Handler) throttled(w http.ResponseWriter, r *http.Request, keys ...string) bool {
+	wait, err := h.limiter.Wait(r.Context(), keys...)
+	if err != nil {
+		slog.Error("failed to check throttle", "error", err)
+		return false
+	}
+	if wait <= 0 {
+		return false
+	}
+
+	writeTooManyRequests(w, wait)
+	return true
+}
+
+// recordAttempts counts an attempt against every key and returns the result
+// for the last one.
+func (h *Handler) recordAttempts(ctx context.Context, keys ...string) throttle.Result {
+	var result throttle.Result
+	for _, key := range keys {
+		var err error
+		if result, err = h.limiter.Record(ctx, key); err != nil {
+			slog.Error("failed to record throttled attempt", "key", key, "error", err)
+		}
+	}
+	return result
+}
+
+func (h *Handler) resetAttempts(ctx context.Context, keys ...string) {
+	if err := h.limiter.Reset(ctx, keys...); err != nil {
+		slog.Error("failed to reset throttle", "error", err)
+	}
+}
+
+// recordLoginFailure counts a failed login against the IP address and the
+// account, and locks the account once it has failed too often. user is nil
+// when no account exists for the email.
+func (h *Handler) recordLoginFailure(ctx context.Context, user *db.User, ipKey, accountKey string) {
+	result := h.recordAttempts(ctx, ipKey, accountKey)
+	if user == nil || result.Attempts < accountLockThreshold {
+		return
+	}
+
+	if err := h.queries.LockUser(ctx, db.LockUserParams{
+		ID:          user.ID,
+		LockedUntil: pgtype.Timestamptz{Time: time.Now().UTC().Add(accountLockDuration), Valid: true},
+	}); err != nil {
+		slog.Error("failed to lock account", "user_id", user.ID, "error", err)
+		return
+	}
+	slog.Warn("account locked after repeated failed logins", "user_id", user.ID)
+}
+
+// checkAccountLock writes a 429 response if user is locked.
+func (h *Handler) checkAccountLock(w http.ResponseWriter, r *http.Request, user *db.User) bool {
+	remaining, err := h.lockRemaining(r.Context(), user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to unlock account")
+		return true
+	}
+	if remaining > 0 {
+		writeTooManyRequests(w, remaining)
+		return true
+	}
+	return false
+}
+
+// lockRemaining returns how long user stays locked. An expired lock is
+// lifted on the way through.
+func (h *Handler) lockRemaining(ctx context.Context, user *db.User) (time.Duration, error) {
+	if user.Status != "locked" {
+		return 0, nil
+	}
+
+	if user.LockedUntil.Valid && user.LockedUntil.Time.After(time.Now()) {
+		return time.Until(user.LockedUntil.Time), nil
+	}
+
+	if _, err := h.queries.UnlockUser(ctx, user.ID); err != nil {
+		return 0, err
+	}
+	h.resetAttempts(ctx, throttle.AccountKey(throttle.ActionLogin, user.Email))
+	user.Status = "active"
+	user.LockedUntil = pgtype.Timestamptz{}
+	return 0, nil
+}
+
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	writeError(w, http.StatusTooManyRequests, "too many attempts, try again later")
+}
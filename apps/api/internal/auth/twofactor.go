@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+	"github.con/falasefemi2/taskflow/api/internal/totp"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+const (
+	totpIssuer        = "TaskFlow"
+	totpSkew          = 1
+	recoveryCodeCount = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type confirmTwoFactorRequest struct {
+	Code string `json:"code"`
+}
+
+type disableTwoFactorRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type loginMFARequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type enrollTwoFactorResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTwoFactor creates a new TOTP secret for the caller. The secret only
+// takes effect once ConfirmTwoFactor has seen a valid code for it.
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	if user.TotpEnabled {
+		writeError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate secret")
+		return
+	}
+
+	if err := h.queries.SetUserTOTPSecret(r.Context(), db.SetUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: pgtype.Text{String: secret, Valid: true},
+	}); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to store secret")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollTwoFactorResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, totpIssuer, user.Email),
+	})
+}
+
+// ConfirmTwoFactor enables 2FA after checking a code from the enrolled
+// secret, and returns the recovery codes. They are shown only this once.
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req confirmTwoFactorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if user.TotpEnabled {
+		writeError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+	if !user.TotpSecret.Valid {
+		writeError(w, http.StatusBadRequest, "two-factor enrolment has not been started")
+		return
+	}
+	if !h.verifyTOTP(r.Context(), user, req.Code) {
+		writeError(w, http.StatusBadRequest, "invalid code")
+		return
+	}
+
+	// The recovery codes and the switch to 2FA commit together, so 2FA is
+	// never on without codes to fall back on.
+	var codes []string
+	err := database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		var err error
+		if codes, err = replaceRecoveryCodes(r.Context(), q, user); err != nil {
+			return err
+		}
+		return q.EnableUserTOTP(r.Context(), user.ID)
+	})
+	if err != nil {
+		slog.Error("failed to enable two-factor authentication", "user_id", user.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to enable two-factor authentication")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns 2FA off. It needs the password and a current
+// second factor so a stolen access token alone cannot remove it.
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req disableTwoFactorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !user.TotpEnabled {
+		writeError(w, http.StatusBadRequest, "two-factor authentication is not enabled")
+		return
+	}
+	if !h.passwords.Matches(req.Password, user.PasswordHash) {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+	if !h.verifySecondFactor(r.Context(), user, req.Code, req.RecoveryCode) {
+		writeError(w, http.StatusUnauthorized, "invalid code")
+		return
+	}
+
+	err := database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if err := q.DisableUserTOTP(r.Context(), user.ID); err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodesByUserID(r.Context(), user.ID)
+	})
+	if err != nil {
+		slog.Error("failed to disable two-factor authentication", "user_id", user.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to disable two-factor authentication")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "two-factor authentication disabled"})
+}
+
+// LoginMFA is the second login step for accounts with 2FA. It exchanges the
+// mfa_pending token from Login plus a TOTP or recovery code for a session.
+func (h *Handler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req loginMFARequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		writeError(w, http.StatusBadRequest, "mfa_token and code or recovery_code are required")
+		return
+	}
+
+	claims, err := h.tokens.Parse(req.MFAToken, token.TypeMFAPending)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid or expired mfa token")
+		return
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid or expired mfa token")
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), userID)
+	if err != nil || !user.TotpEnabled {
+		writeError(w, http.StatusUnauthorized, "invalid or expired mfa token")
+		return
+	}
+	if accountDisabled(user) {
+		writeError(w, http.StatusForbidden, "account suspended")
+		return
+	}
+
+	ipKey := throttle.IPKey(throttle.ActionMFA, utils.ClientIP(r))
+	accountKey := throttle.AccountKey(throttle.ActionMFA, user.Email)
+	if h.throttled(w, r, ipKey, accountKey) || h.checkAccountLock(w, r, &user) {
+		return
+	}
+
+	if !h.verifySecondFactor(r.Context(), user, req.Code, req.RecoveryCode) {
+		h.recordLoginFailure(r.Context(), &user, ipKey, accountKey)
+		writeError(w, http.StatusUnauthorized, "invalid code")
+		return
+	}
+	h.resetAttempts(r.Context(), accountKey)
+
+	h.completeLogin(w, r, user)
+}
+
+// currentUser loads the authenticated user, writing an error response and
+// returning false if that fails.
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (db.User, bool) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return db.User{}, false
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "user not found")
+		return db.User{}, false
+	}
+	return user, true
+}
+
+func (h *Handler) verifySecondFactor(ctx context.Context, user db.User, code, recoveryCode string) bool {
+	if code != "" {
+		return h.verifyTOTP(ctx, user, code)
+	}
+	if recoveryCode == "" {
+		return false
+	}
+
+	used, err := h.queries.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: token.Hash(normalizeRecoveryCode(recoveryCode)),
+	})
+	return err == nil && used == 1
+}
+
+// verifyTOTP checks code and marks its time step as used, so each code is
+// accepted at most once.
+func (h *Handler) verifyTOTP(ctx context.Context, user db.User, code string) bool {
+	if !user.TotpSecret.Valid {
+		return false
+	}
+	step, ok := totp.Validate(user.TotpSecret.String, code, time.Now(), totpSkew)
+	if !ok {
+		return false
+	}
+
+	consumed, err := h.queries.ConsumeUserTOTPStep(ctx, db.ConsumeUserTOTPStepParams{
+		Step: step,
+		ID:   user.ID,
+	})
+	return err == nil && consumed == 1
+}
+
+// replaceRecoveryCodes swaps the user's recovery codes for new ones through
+// q, and returns the new codes in plain text.
+func replaceRecoveryCodes(ctx context.Context, q *db.Queries, user db.User) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = token.Hash(normalizeRecoveryCode(code))
+	}
+
+	if err := q.DeleteRecoveryCodesByUserID(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := q.CreateRecoveryCodes(ctx, db.CreateRecoveryCodesParams{
+		UserID:     user.ID,
+		CodeHashes: hashes,
+	}); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code like "k7m2q-x9ptd".
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(strings.ReplaceAll(code, "-", ""), " ", "")
+}
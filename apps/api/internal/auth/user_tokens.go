@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+)
+
+// Purposes of single-use tokens emailed to users. A token only works for the
+// purpose it was issued for.
+const (
+	purposePasswordReset = "password_reset"
+	purposeMagicLink     = "magic_link"
+)
+
+// issueUserToken creates a single-use token for userID and returns it raw.
+// Only its hash is stored, and any earlier token for the same purpose stops
+// working.
+func (h *Handler) issueUserToken(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	rawToken, err := token.GenerateOpaque(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := h.queries.DeleteUserTokens(ctx, db.DeleteUserTokensParams{
+		UserID:  userID,
+		Purpose: purpose,
+	}); err != nil {
+		return "", err
+	}
+
+	if _, err := h.queries.CreateUserToken(ctx, db.CreateUserTokenParams{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: token.Hash(rawToken),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().UTC().Add(ttl), Valid: true},
+	}); err != nil {
+		return "", err
+	}
+	return rawToken, nil
+}
+
+// userForToken returns the user an unexpired, unused token for purpose was
+// issued to, without using it up.
+func (h *Handler) userForToken(ctx context.Context, rawToken, purpose string) (db.User, error) {
+	active, err := h.queries.GetActiveUserToken(ctx, db.GetActiveUserTokenParams{
+		TokenHash: token.Hash(rawToken),
+		Purpose:   purpose,
+	})
+	if err != nil {
+		return db.User{}, token.ErrInvalidToken
+	}
+	return h.queries.GetUserByID(ctx, active.UserID)
+}
+
+// consumeUserToken marks an unexpired token for purpose as used and returns
+// the user it was issued to. Consuming is atomic, so a token cannot be used
+// twice even by concurrent requests.
+func (h *Handler) consumeUserToken(ctx context.Context, rawToken, purpose string) (db.User, error) {
+	consumed, err := h.queries.ConsumeUserToken(ctx, db.ConsumeUserTokenParams{
+		TokenHash: token.Hash(rawToken),
+		Purpose:   purpose,
+	})
+	if err != nil {
+		return db.User{}, token.ErrInvalidToken
+	}
+	return h.queries.GetUserByID(ctx, consumed.UserID)
+}
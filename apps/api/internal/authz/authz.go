@@ -0,0 +1,198 @@
+// Package authz decides what a user may do inside a workspace. Permissions
+// follow from the user's role in the workspace, which a role on a project
+// can override for that project and its tasks.
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Roles, from most to least privileged. Workspace members and project
+// members use the same set.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleGuest  = "guest"
+)
+
+var Roles = []string{RoleOwner, RoleAdmin, RoleMember, RoleGuest}
+
+// Action is something a user can be permitted to do, named
+// "<resource>.<verb>".
+type Action string
+
+const (
+	WorkspaceRead     Action = "workspace.read"
+	WorkspaceUpdate   Action = "workspace.update"
+	WorkspaceDelete   Action = "workspace.delete"
+	WorkspaceTransfer Action = "workspace.transfer"
+
+	MembersRead       Action = "members.read"
+	MembersManage     Action = "members.manage"
+	InvitationsManage Action = "invitations.manage"
+
+	ProjectCreate        Action = "project.create"
+	ProjectRead          Action = "project.read"
+	ProjectUpdate        Action = "project.update"
+	ProjectDelete        Action = "project.delete"
+	ProjectMembersManage Action = "project.members.manage"
+
+	TaskRead    Action = "task.read"
+	TaskCreate  Action = "task.create"
+	TaskUpdate  Action = "task.update"
+	TaskDelete  Action = "task.delete"
+	TaskAssign  Action = "task.assign"
+	TaskComment Action = "task.comment"
+)
+
+var (
+	// ErrForbidden is returned when the subject may not perform the action.
+	ErrForbidden = errors.New("access denied")
+	// ErrNotMember is returned when the subject is not a member of the
+	// workspace at all. It wraps ErrForbidden.
+	ErrNotMember = fmt.Errorf("%w: not a workspace member", ErrForbidden)
+)
+
+var permissions = map[string][]Action{
+	RoleOwner: {
+		WorkspaceRead, WorkspaceUpdate, WorkspaceDelete, WorkspaceTransfer,
+		MembersRead, MembersManage, InvitationsManage,
+		ProjectCreate, ProjectRead, ProjectUpdate, ProjectDelete, ProjectMembersManage,
+		TaskRead, TaskCreate, TaskUpdate, TaskDelete, TaskAssign, TaskComment,
+	},
+	RoleAdmin: {
+		WorkspaceRead, WorkspaceUpdate,
+		MembersRead, MembersManage, InvitationsManage,
+		ProjectCreate, ProjectRead, ProjectUpdate, ProjectDelete, ProjectMembersManage,
+		TaskRead, TaskCreate, TaskUpdate, TaskDelete, TaskAssign, TaskComment,
+	},
+	RoleMember: {
+		WorkspaceRead,
+		MembersRead,
+		ProjectCreate, ProjectRead,
+		TaskRead, TaskCreate, TaskUpdate, TaskAssign, TaskComment,
+	},
+	RoleGuest: {
+		WorkspaceRead,
+		MembersRead,
+		ProjectRead,
+		TaskRead, TaskComment,
+	},
+}
+
+// projectActions are the actions a project role can override. Creating a
+// project is not among them: there is no project yet.
+var projectActions = []Action{
+	ProjectRead, ProjectUpdate, ProjectDelete, ProjectMembersManage,
+	TaskRead, TaskCreate, TaskUpdate, TaskDelete, TaskAssign, TaskComment,
+}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// Can reports whether role grants action.
+func Can(role string, action Action) bool {
+	return slices.Contains(permissions[role], action)
+}
+
+// RolesWith returns the roles that grant action, for filtering lists in SQL
+// the same way Can filters a single membership.
+func RolesWith(action Action) []string {
+	var roles []string
+	for _, role := range Roles {
+		if Can(role, action) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// EffectiveRole returns the role that applies inside a project. A project
+// role replaces the workspace role in either direction, except that
+// workspace owners and admins keep their role in every project so they
+// cannot be locked out of their own workspace. Without a workspace role
+// there is no access, whatever the project says.
+func EffectiveRole(workspaceRole, projectRole string) string {
+	switch {
+	case workspaceRole == "":
+		return ""
+	case workspaceRole == RoleOwner, workspaceRole == RoleAdmin:
+		return workspaceRole
+	case projectRole != "":
+		return projectRole
+	default:
+		return workspaceRole
+	}
+}
+
+// Subject is the user asking to act.
+type Subject struct {
+	UserID uuid.UUID
+}
+
+// Resource is what the action applies to. ProjectID is uuid.Nil for
+// workspace-level actions, and the project role only counts for actions on
+// the project and its tasks.
+type Resource struct {
+	WorkspaceID uuid.UUID
+	ProjectID   uuid.UUID
+}
+
+// Workspace returns the resource for a workspace-level action.
+func Workspace(workspaceID uuid.UUID) Resource {
+	return Resource{WorkspaceID: workspaceID}
+}
+
+// Project returns the resource for an action on a project or its tasks.
+func Project(workspaceID, projectID uuid.UUID) Resource {
+	return Resource{WorkspaceID: workspaceID, ProjectID: projectID}
+}
+
+// RoleSource looks up memberships. Both methods return "" and no error when
+// the user has no role.
+type RoleSource interface {
+	WorkspaceRole(ctx context.Context, workspaceID, userID uuid.UUID) (string, error)
+	ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (string, error)
+}
+
+type Authorizer struct {
+	roles RoleSource
+}
+
+func New(roles RoleSource) *Authorizer {
+	return &Authorizer{roles: roles}
+}
+
+// Authorize returns nil if subject may perform action on resource,
+// ErrNotMember if they do not belong to the workspace, and ErrForbidden if
+// their role does not allow it.
+func (a *Authorizer) Authorize(ctx context.Context, subject Subject, action Action, resource Resource) error {
+	role, err := a.roles.WorkspaceRole(ctx, resource.WorkspaceID, subject.UserID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrNotMember
+	}
+
+	if resource.ProjectID != uuid.Nil && slices.Contains(projectActions, action) {
+		projectRole, err := a.roles.ProjectRole(ctx, resource.ProjectID, subject.UserID)
+		if err != nil {
+			return err
+		}
+		role = EffectiveRole(role, projectRole)
+	}
+
+	if !Can(role, action) {
+		return ErrForbidden
+	}
+	return nil
+}
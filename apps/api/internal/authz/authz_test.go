@@ -0,0 +1,160 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	testUser      = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	testWorkspace = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	testProject   = uuid.MustParse("00000000-0000-0000-0000-0000000000b0")
+)
+
+// fakeRoles gives testUser fixed roles in testWorkspace and testProject.
+type fakeRoles struct {
+	workspace string
+	project   string
+	err       error
+}
+
+func (f fakeRoles) WorkspaceRole(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+	if workspaceID != testWorkspace || userID != testUser {
+		return "", f.err
+	}
+	return f.workspace, f.err
+}
+
+func (f fakeRoles) ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (string, error) {
+	if projectID != testProject || userID != testUser {
+		return "", f.err
+	}
+	return f.project, f.err
+}
+
+func TestRoleMatrix(t *testing.T) {
+	tests := []struct {
+		action                      Action
+		owner, admin, member, guest bool
+	}{
+		{WorkspaceRead, true, true, true, true},
+		{WorkspaceUpdate, true, true, false, false},
+		{WorkspaceDelete, true, false, false, false},
+		{WorkspaceTransfer, true, false, false, false},
+		{MembersRead, true, true, true, true},
+		{MembersManage, true, true, false, false},
+		{InvitationsManage, true, true, false, false},
+		{ProjectCreate, true, true, true, false},
+		{ProjectRead, true, true, true, true},
+		{ProjectUpdate, true, true, false, false},
+		{ProjectDelete, true, true, false, false},
+		{ProjectMembersManage, true, true, false, false},
+		{TaskRead, true, true, true, true},
+		{TaskCreate, true, true, true, false},
+		{TaskUpdate, true, true, true, false},
+		{TaskDelete, true, true, false, false},
+		{TaskAssign, true, true, true, false},
+		{TaskComment, true, true, true, true},
+	}
+
+	for _, tt := range tests {
+		for role, want := range map[string]bool{
+			RoleOwner:  tt.owner,
+			RoleAdmin:  tt.admin,
+			RoleMember: tt.member,
+			RoleGuest:  tt.guest,
+		} {
+			t.Run(string(tt.action)+"/"+role, func(t *testing.T) {
+				a := New(fakeRoles{workspace: role})
+				err := a.Authorize(context.Background(), Subject{UserID: testUser}, tt.action, Workspace(testWorkspace))
+				if want && err != nil {
+					t.Fatalf("expected %s to be allowed %s, got %v", role, tt.action, err)
+				}
+				if !want && !errors.Is(err, ErrForbidden) {
+					t.Fatalf("expected %s to be denied %s, got %v", role, tt.action, err)
+				}
+			})
+		}
+	}
+}
+
+func TestProjectRoleOverrides(t *testing.T) {
+	tests := []struct {
+		name          string
+		workspaceRole string
+		projectRole   string
+		action        Action
+		want          error
+	}{
+		{"guest promoted to member can create tasks", RoleGuest, RoleMember, TaskCreate, nil},
+		{"guest promoted to admin can update project", RoleGuest, RoleAdmin, ProjectUpdate, nil},
+		{"member promoted to admin can delete tasks", RoleMember, RoleAdmin, TaskDelete, nil},
+		{"member demoted to guest cannot create tasks", RoleMember, RoleGuest, TaskCreate, ErrForbidden},
+		{"member demoted to guest can still comment", RoleMember, RoleGuest, TaskComment, nil},
+		{"member without project role keeps workspace role", RoleMember, "", TaskCreate, nil},
+		{"guest without project role keeps workspace role", RoleGuest, "", TaskCreate, ErrForbidden},
+		{"admin is not demoted by project role", RoleAdmin, RoleGuest, ProjectDelete, nil},
+		{"owner is not demoted by project role", RoleOwner, RoleGuest, TaskDelete, nil},
+		{"project admin gains no workspace powers", RoleMember, RoleAdmin, WorkspaceUpdate, ErrForbidden},
+		{"project role without membership grants nothing", "", RoleAdmin, TaskRead, ErrNotMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(fakeRoles{workspace: tt.workspaceRole, project: tt.projectRole})
+			err := a.Authorize(context.Background(), Subject{UserID: testUser}, tt.action, Project(testWorkspace, testProject))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestProjectRoleIgnoredForWorkspaceActions(t *testing.T) {
+	a := New(fakeRoles{workspace: RoleGuest, project: RoleAdmin})
+	err := a.Authorize(context.Background(), Subject{UserID: testUser}, ProjectCreate, Workspace(testWorkspace))
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestAuthorizeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles fakeRoles
+		user  uuid.UUID
+		want  error
+	}{
+		{"non-member", fakeRoles{}, testUser, ErrNotMember},
+		{"other user", fakeRoles{workspace: RoleOwner}, uuid.New(), ErrNotMember},
+		{"unknown role", fakeRoles{workspace: "superuser"}, testUser, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.roles).Authorize(context.Background(), Subject{UserID: tt.user}, WorkspaceRead, Workspace(testWorkspace))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	lookupErr := errors.New("connection refused")
+	err := New(fakeRoles{err: lookupErr}).Authorize(context.Background(), Subject{UserID: testUser}, WorkspaceRead, Workspace(testWorkspace))
+	if !errors.Is(err, lookupErr) || errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected lookup error to be returned as is, got %v", err)
+	}
+}
+
+func TestRolesWith(t *testing.T) {
+	if got := RolesWith(WorkspaceDelete); !slices.Equal(got, []string{RoleOwner}) {
+		t.Fatalf("expected [%s], got %v", RoleOwner, got)
+	}
+	if got := RolesWith(WorkspaceRead); !slices.Equal(got, Roles) {
+		t.Fatalf("expected %v, got %v", Roles, got)
+	}
+}
@@ -0,0 +1,42 @@
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+)
+
+type queryRoles struct {
+	queries *db.Queries
+}
+
+// QueryRoles returns a RoleSource backed by the workspace_members and
+// project_members tables.
+func QueryRoles(queries *db.Queries) RoleSource {
+	return queryRoles{queries: queries}
+}
+
+func (s queryRoles) WorkspaceRole(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+	member, err := s.queries.GetWorkspaceMember(ctx, db.GetWorkspaceMemberParams{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return member.Role, err
+}
+
+func (s queryRoles) ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (string, error) {
+	member, err := s.queries.GetProjectMember(ctx, db.GetProjectMemberParams{
+		ProjectID: projectID,
+		UserID:    userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return member.Role, err
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -11,15 +12,23 @@ import (
 )
 
 type Config struct {
-	Primary  PrimaryConfig  `validate:"required"`
-	Server   ServerConfig   `validate:"required"`
-	Database DatabaseConfig `validate:"required"`
-	Auth     AuthConfig     `validate:"required"`
-	Email    EmailConfig    `validate:"required"`
+	Primary   PrimaryConfig  `validate:"required"`
+	Server    ServerConfig   `validate:"required"`
+	Database  DatabaseConfig `validate:"required"`
+	Auth      AuthConfig     `validate:"required"`
+	Email     EmailConfig    `validate:"required"`
+	Password  PasswordConfig `validate:"required"`
+	Scheduler SchedulerConfig
+	Jobs      JobsConfig `validate:"required"`
+	OIDC      OIDCConfig
 }
 
 type PrimaryConfig struct {
-	Env string `validate:"required,oneof=development staging production"`
+	Env    string `validate:"required,oneof=development staging production"`
+	AppURL string `validate:"required,url"`
+	// APIURL is the public base URL of this API, used to build callback URLs
+	// for external services.
+	APIURL string `validate:"required,url"`
 }
 
 type ServerConfig struct {
@@ -28,6 +37,9 @@ type ServerConfig struct {
 	WriteTimeout  time.Duration `validate:"required"`
 	IdleTimeout   time.Duration `validate:"required"`
 	AllowedOrigin string        `validate:"required"`
+	// TrustProxy makes the server take the client IP from X-Forwarded-For
+	// and X-Real-IP. Enable it only behind a proxy that sets those headers.
+	TrustProxy bool
 }
 
 type DatabaseConfig struct {
@@ -39,21 +51,88 @@ type DatabaseConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret string `validate:"required,min=32"`
+	// JWTAlgorithm selects how access tokens are signed. HS256 uses
+	// JWTSecret; RS256 and EdDSA use the PEM key in JWTPrivateKeyFile.
+	JWTAlgorithm      string `validate:"required,oneof=HS256 RS256 EdDSA"`
+	JWTSecret         string `validate:"required_if=JWTAlgorithm HS256,omitempty,min=32"`
+	JWTPrivateKeyFile string `validate:"required_unless=JWTAlgorithm HS256"`
+	JWTKeyID          string `validate:"required"`
+	// JWTVerificationKeys lists extra keys that are still accepted during
+	// rotation, as comma-separated kid=path entries.
+	JWTVerificationKeys  string
+	JWTIssuer            string `validate:"required"`
+	JWTAudience          string `validate:"required"`
+	RequireVerifiedEmail bool
+}
+
+type PasswordConfig struct {
+	MinLength int `validate:"min=8,max=128"`
+	// CheckCommon rejects passwords on the bundled list of common and
+	// breached passwords.
+	CheckCommon bool
+	// Argon2id cost for new hashes. Memory is in KiB. Existing hashes made
+	// with other parameters are upgraded when their user next logs in.
+	Argon2Memory      int `validate:"min=8192"`
+	Argon2Iterations  int `validate:"min=1"`
+	Argon2Parallelism int `validate:"min=1,max=255"`
+}
+
+type SchedulerConfig struct {
+	// Enabled runs periodic maintenance jobs in this process. Jobs are
+	// coordinated through Postgres, so leaving it on in every replica is safe.
+	Enabled bool
+	// TrashRetentionDays is how long deleted workspaces, projects and tasks
+	// can be restored before they are purged for good.
+	TrashRetentionDays int `validate:"min=1"`
+}
+
+type JobsConfig struct {
+	// Concurrency is how many jobs this process runs at once.
+	Concurrency int `validate:"min=1"`
 }
 
 type EmailConfig struct {
-	ResendAPIKey string `validate:"required"`
+	Driver       string `validate:"required,oneof=resend smtp file memory"`
 	FromEmail    string `validate:"required,email"`
+	ResendAPIKey string `validate:"required_if=Driver resend"`
+	SMTPHost     string `validate:"required_if=Driver smtp"`
+	SMTPPort     int    `validate:"required_if=Driver smtp"`
+	SMTPUsername string
+	SMTPPassword string
+	// FileDir is where the file driver writes .eml files. Empty prints
+	// messages to stdout.
+	FileDir string
+}
+
+// OIDCConfig lists the external identity providers users can sign in with.
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `validate:"dive"`
+	// StateSecret signs the short-lived cookie that carries the state, nonce
+	// and PKCE verifier between the login redirect and the callback.
+	StateSecret string `validate:"required_with=Providers,omitempty,min=32"`
+}
+
+type OIDCProviderConfig struct {
+	// Name identifies the provider in URLs and in user_identities.
+	Name         string `validate:"required,alphanum,lowercase"`
+	IssuerURL    string `validate:"required,url"`
+	ClientID     string `validate:"required"`
+	ClientSecret string
+	Scopes       []string `validate:"required"`
+	RedirectURL  string   `validate:"required,url"`
 }
 
 func Load() (*Config, error) {
 	// Support running from either apps/api or repo root.
 	_ = godotenv.Load(".env", "apps/api/.env")
 
+	env := getEnv("APP_ENV", "development")
+
 	cfg := &Config{
 		Primary: PrimaryConfig{
-			Env: getEnv("APP_ENV", "development"),
+			Env:    env,
+			AppURL: getEnv("APP_URL", "http://localhost:5173"),
+			APIURL: getEnv("API_URL", "http://localhost:8080"),
 		},
 		Server: ServerConfig{
 			Port:          getEnvAsInt("PORT", 8080),
@@ -61,6 +140,7 @@ func Load() (*Config, error) {
 			WriteTimeout:  getEnvAsDuration("WRITE_TIMEOUT", 10*time.Second),
 			IdleTimeout:   getEnvAsDuration("IDLE_TIMEOUT", 60*time.Second),
 			AllowedOrigin: getEnv("ALLOWED_ORIGIN", "http://localhost:5173"),
+			TrustProxy:    getEnvAsBool("TRUST_PROXY", false),
 		},
 		Database: DatabaseConfig{
 			URL:             getEnv("DATABASE_URL", ""),
@@ -70,13 +150,41 @@ func Load() (*Config, error) {
 			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 30*time.Minute),
 		},
 		Auth: AuthConfig{
-			JWTSecret: getEnv("JWT_SECRET", ""),
+			JWTAlgorithm:         getEnv("JWT_ALGORITHM", "HS256"),
+			JWTSecret:            getEnv("JWT_SECRET", ""),
+			JWTPrivateKeyFile:    getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			JWTKeyID:             getEnv("JWT_KEY_ID", "primary"),
+			JWTVerificationKeys:  getEnv("JWT_VERIFICATION_KEYS", ""),
+			JWTIssuer:            getEnv("JWT_ISSUER", "taskflow"),
+			JWTAudience:          getEnv("JWT_AUDIENCE", "taskflow-api"),
+			RequireVerifiedEmail: getEnvAsBool("REQUIRE_VERIFIED_EMAIL", false),
 		},
 		Email: EmailConfig{
-			ResendAPIKey: getEnv("RESEND_API_KEY", ""),
+			Driver:       getEnv("EMAIL_DRIVER", defaultEmailDriver(env)),
 			FromEmail:    getEnv("FROM_EMAIL", "onboarding@resend.dev"),
+			ResendAPIKey: getEnv("RESEND_API_KEY", ""),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			FileDir:      getEnv("EMAIL_FILE_DIR", ""),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:            getEnvAsBool("SCHEDULER_ENABLED", true),
+			TrashRetentionDays: getEnvAsInt("TRASH_RETENTION_DAYS", 30),
+		},
+		Jobs: JobsConfig{
+			Concurrency: getEnvAsInt("JOBS_CONCURRENCY", 4),
+		},
+		Password: PasswordConfig{
+			MinLength:         getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			CheckCommon:       getEnvAsBool("PASSWORD_CHECK_COMMON", true),
+			Argon2Memory:      getEnvAsInt("PASSWORD_ARGON2_MEMORY", 19*1024),
+			Argon2Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 2),
+			Argon2Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 1),
 		},
 	}
+	cfg.OIDC = loadOIDCConfig(cfg.Primary.APIURL)
 
 	validate := validator.New()
 	if err := validate.Struct(cfg); err != nil {
@@ -86,6 +194,41 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// loadOIDCConfig reads the providers named in OIDC_PROVIDERS. Each provider
+// is configured through OIDC_<NAME>_ISSUER_URL, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and optionally OIDC_<NAME>_SCOPES.
+func loadOIDCConfig(apiURL string) OIDCConfig {
+	cfg := OIDCConfig{StateSecret: getEnv("OIDC_STATE_SECRET", "")}
+
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		cfg.Providers = append(cfg.Providers, OIDCProviderConfig{
+			Name:         name,
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(strings.ReplaceAll(getEnv(prefix+"SCOPES", "openid email profile"), ",", " ")),
+			RedirectURL: getEnv(prefix+"REDIRECT_URL",
+				strings.TrimRight(apiURL, "/")+"/api/v1/auth/oidc/"+name+"/callback"),
+		})
+	}
+	return cfg
+}
+
+// defaultEmailDriver keeps production on Resend while letting local and
+// staging setups run without an API key.
+func defaultEmailDriver(env string) string {
+	if env == "production" {
+		return "resend"
+	}
+	return "file"
+}
+
 func getEnv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -105,6 +248,18 @@ func getEnvAsInt(key string, fallback int) int {
 	return val
 }
 
+func getEnvAsBool(key string, fallback bool) bool {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return fallback
+	}
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		panic(fmt.Sprintf("invalid boolean for %s", key))
+	}
+	return val
+}
+
 func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
 	valStr := os.Getenv(key)
 	if valStr == "" {
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+)
+
+// WithTx runs fn in a transaction, committing only if fn succeeds.
+func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(q *db.Queries) error) error {
+	return WithPgxTx(ctx, pool, func(tx pgx.Tx) error {
+		return fn(db.New(tx))
+	})
+}
+
+// WithPgxTx is WithTx for callers that need the transaction itself, for
+// instance to open a Savepoint.
+func WithPgxTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Savepoint runs fn under a savepoint of tx. If fn fails only its own work
+// is rolled back and tx stays usable, so a statement that is expected to
+// fail, such as a delete blocked by a foreign key, does not abort the rest
+// of the transaction. fn's error is returned.
+func Savepoint(ctx context.Context, tx pgx.Tx, fn func(q *db.Queries) error) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(db.New(savepoint)); err != nil {
+		if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+	return savepoint.Commit(ctx)
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+)
+
+// SendEmail delivers one outgoing email.
+var SendEmail = NewType[mailer.Message]("send_email")
+
+// Mailer is a mailer.Mailer that queues messages as SendEmail jobs instead
+// of delivering them, so a slow or failing provider does not hold up the
+// request and failed deliveries are retried.
+type Mailer struct {
+	queries *db.Queries
+}
+
+func NewMailer(conn db.DBTX) *Mailer {
+	return &Mailer{queries: db.New(conn)}
+}
+
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	_, err := Enqueue(ctx, m.queries, SendEmail, msg)
+	return err
+}
+
+// HandleEmail registers the handler that delivers queued email through mail.
+func HandleEmail(w *Worker, mail mailer.Mailer) {
+	Handle(w, SendEmail, mail.Send)
+}
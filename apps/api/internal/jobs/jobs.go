@@ -0,0 +1,86 @@
+// Package jobs is a durable background job queue stored in Postgres. Jobs
+// are enqueued with the same queries value as the surrounding domain write,
+// so enqueueing inside a transaction commits or rolls back with it. Workers
+// claim jobs with FOR UPDATE SKIP LOCKED, so any number of replicas can run
+// them. A job's payload is cleared once it completes or dies, so secrets it
+// carries, such as the links in queued emails, are not kept with the
+// finished job.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+)
+
+const defaultMaxAttempts = 5
+
+// Type names a kind of job and the payload it carries. Declare one per kind
+// with NewType and use it both to enqueue and to register the handler, so the
+// two cannot disagree about the payload.
+type Type[T any] struct {
+	kind string
+}
+
+func NewType[T any](kind string) Type[T] {
+	return Type[T]{kind: kind}
+}
+
+func (t Type[T]) Kind() string {
+	return t.kind
+}
+
+type enqueueOptions struct {
+	runAt       time.Time
+	uniqueKey   string
+	maxAttempts int
+}
+
+type Option func(*enqueueOptions)
+
+// RunAt delays the job until t.
+func RunAt(t time.Time) Option {
+	return func(o *enqueueOptions) { o.runAt = t }
+}
+
+// UniqueKey drops the job if another job of the same type with the same key
+// is still pending or running.
+func UniqueKey(key string) Option {
+	return func(o *enqueueOptions) { o.uniqueKey = key }
+}
+
+// MaxAttempts sets how many times the job runs before it is dead-lettered.
+func MaxAttempts(n int) Option {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+// Enqueue adds a job. Pass queries bound to a transaction to enqueue
+// atomically with other writes. enqueued is false if a UniqueKey duplicate
+// was dropped.
+func Enqueue[T any](ctx context.Context, queries *db.Queries, t Type[T], payload T, opts ...Option) (enqueued bool, err error) {
+	o := enqueueOptions{runAt: time.Now(), maxAttempts: defaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("encode %s payload: %w", t.kind, err)
+	}
+
+	rows, err := queries.EnqueueJob(ctx, db.EnqueueJobParams{
+		Kind:        t.kind,
+		Payload:     raw,
+		MaxAttempts: int32(o.maxAttempts),
+		RunAt:       pgtype.Timestamptz{Time: o.runAt, Valid: true},
+		UniqueKey:   pgtype.Text{String: o.uniqueKey, Valid: o.uniqueKey != ""},
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/config"
+)
+
+const (
+	// pollInterval is how long an idle worker waits before looking for
+	// jobs again.
+	pollInterval = time.Second
+	// jobTimeout bounds a single run of a job.
+	jobTimeout = 5 * time.Minute
+	// stallTimeout is how long a job may stay running before it is assumed
+	// that its worker died and the job is put back in the queue.
+	stallTimeout   = 3 * jobTimeout
+	baseRetryDelay = 10 * time.Second
+	maxRetryDelay  = time.Hour
+)
+
+type handlerFunc func(ctx context.Context, payload []byte) error
+
+type Worker struct {
+	queries     *db.Queries
+	concurrency int
+	handlers    map[string]handlerFunc
+
+	// stopClaiming ends the claim loops; cancelJobs interrupts jobs that
+	// are still running when shutdown runs out of time.
+	stopClaiming context.CancelFunc
+	cancelJobs   context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+func NewWorker(pool *pgxpool.Pool, cfg config.JobsConfig) *Worker {
+	return &Worker{
+		queries:     db.New(pool),
+		concurrency: cfg.Concurrency,
+		handlers:    make(map[string]handlerFunc),
+	}
+}
+
+// Handle registers fn to run jobs of type t. It must be called before Start.
+func Handle[T any](w *Worker, t Type[T], fn func(ctx context.Context, payload T) error) {
+	w.handlers[t.kind] = func(ctx context.Context, raw []byte) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("decode %s payload: %w", t.kind, err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Start runs the worker's claim loops until Stop is called.
+func (w *Worker) Start() {
+	claimCtx, stopClaiming := context.WithCancel(context.Background())
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	w.stopClaiming, w.cancelJobs = stopClaiming, cancelJobs
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.requeueStalled(claimCtx)
+	}()
+
+	for range w.concurrency {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.loop(claimCtx, jobCtx)
+		}()
+	}
+	slog.Info("job worker started", "concurrency", w.concurrency)
+}
+
+// Stop stops claiming new jobs and waits for running ones to finish. If ctx
+// is done first, running jobs are cancelled and retried later.
+func (w *Worker) Stop(ctx context.Context) error {
+	if w.stopClaiming == nil {
+		return nil
+	}
+	w.stopClaiming()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancelJobs()
+		slog.Info("job worker stopped")
+		return nil
+	case <-ctx.Done():
+		w.cancelJobs()
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) loop(claimCtx, jobCtx context.Context) {
+	for {
+		jobs, err := w.queries.ClaimJobs(claimCtx, 1)
+		if err != nil && claimCtx.Err() == nil {
+			slog.Error("failed to claim jobs", "error", err)
+		}
+		for _, job := range jobs {
+			w.run(jobCtx, job)
+		}
+		if len(jobs) > 0 {
+			continue
+		}
+
+		select {
+		case <-claimCtx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (w *Worker) run(ctx context.Context, job db.Job) {
+	err := w.execute(ctx, job)
+
+	// Record the outcome even if the job was cancelled by shutdown.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	switch {
+	case err == nil:
+		err = w.queries.CompleteJob(ctx, job.ID)
+	case job.Attempts >= job.MaxAttempts:
+		slog.Error("job failed permanently", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		err = w.queries.KillJob(ctx, db.KillJobParams{
+			ID:        job.ID,
+			LastError: pgtype.Text{String: err.Error(), Valid: true},
+		})
+	default:
+		delay := retryDelay(int(job.Attempts))
+		slog.Warn("job failed, retrying", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "retry_in", delay, "error", err)
+		err = w.queries.RetryJob(ctx, db.RetryJobParams{
+			ID:        job.ID,
+			RunAt:     pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true},
+			LastError: pgtype.Text{String: err.Error(), Valid: true},
+		})
+	}
+	if err != nil {
+		slog.Error("failed to record job outcome", "job_id", job.ID, "error", err)
+	}
+}
+
+func (w *Worker) execute(ctx context.Context, job db.Job) (err error) {
+	handler, ok := w.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+	return handler(ctx, job.Payload)
+}
+
+// requeueStalled periodically returns jobs whose worker died mid-run to the
+// queue.
+func (w *Worker) requeueStalled(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		n, err := w.queries.RequeueStalledJobs(ctx, pgtype.Timestamptz{
+			Time:  time.Now().Add(-stallTimeout),
+			Valid: true,
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("failed to requeue stalled jobs", "error", err)
+		}
+		if n > 0 {
+			slog.Warn("requeued stalled jobs", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryDelay backs off exponentially from baseRetryDelay, with jitter so
+// jobs that failed together do not retry together.
+func retryDelay(attempts int) time.Duration {
+	delay := float64(baseRetryDelay) * math.Pow(2, float64(attempts-1))
+	if delay > float64(maxRetryDelay) {
+		delay = float64(maxRetryDelay)
+	}
+	return time.Duration(delay * (0.75 + rand.Float64()/2))
+}
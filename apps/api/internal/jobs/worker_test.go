@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		base     time.Duration
+	}{
+		{1, baseRetryDelay},
+		{2, 2 * baseRetryDelay},
+		{3, 4 * baseRetryDelay},
+		{5, 16 * baseRetryDelay},
+		{9, 256 * baseRetryDelay},
+		{10, maxRetryDelay},
+		{50, maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		lo := time.Duration(float64(tt.base) * 0.75)
+		hi := time.Duration(float64(tt.base) * 1.25)
+		for range 100 {
+			if got := retryDelay(tt.attempts); got < lo || got > hi {
+				t.Fatalf("retryDelay(%d) = %v, want between %v and %v", tt.attempts, got, lo, hi)
+			}
+		}
+	}
+}
+
+type testPayload struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestHandle(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name    string
+		raw     string
+		err     error
+		want    testPayload
+		wantErr bool
+	}{
+		{name: "decodes payload", raw: `{"id":7,"name":"report"}`, want: testPayload{ID: 7, Name: "report"}},
+		{name: "empty object", raw: `{}`, want: testPayload{}},
+		{name: "malformed json", raw: `{"id":`, wantErr: true},
+		{name: "wrong type", raw: `{"id":"seven"}`, wantErr: true},
+		{name: "handler error", raw: `{"id":1}`, err: errHandler, want: testPayload{ID: 1}, wantErr: true},
+	}
+
+	kind := NewType[testPayload]("test")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Worker{handlers: make(map[string]handlerFunc)}
+			var got testPayload
+			called := false
+			Handle(w, kind, func(ctx context.Context, p testPayload) error {
+				called, got = true, p
+				return tt.err
+			})
+
+			err := w.handlers[kind.Kind()](context.Background(), []byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.wantErr && tt.err == nil {
+				if called {
+					t.Fatal("handler called with an undecodable payload")
+				}
+				return
+			}
+			if got != tt.want {
+				t.Fatalf("payload = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
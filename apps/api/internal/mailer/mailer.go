@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.con/falasefemi2/taskflow/api/internal/config"
+)
+
+// Message is a single outgoing email. HTML is optional; every message
+// carries a plain-text body.
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Mailer delivers outgoing email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the Mailer selected by cfg.Driver.
+func New(cfg config.EmailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "resend":
+		return NewResendMailer(cfg.ResendAPIKey, cfg.FromEmail), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.FromEmail), nil
+	case "file":
+		if cfg.FileDir == "" {
+			return NewWriterMailer(os.Stdout, cfg.FromEmail), nil
+		}
+		return NewFileMailer(cfg.FileDir, cfg.FromEmail)
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown email driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Template names an email template in templates/. <name>.txt defines the
+// "<name>:subject" and "<name>:text" blocks and <name>.html the "<name>:html"
+// block.
+type Template string
+
+const (
+	TemplatePasswordReset       Template = "password_reset"
+	TemplatePasswordChanged     Template = "password_changed"
+	TemplateVerifyEmail         Template = "verify_email"
+	TemplateEmailChange         Template = "email_change"
+	TemplateMagicLink           Template = "magic_link"
+	TemplateWorkspaceInvitation Template = "workspace_invitation"
+	TemplateWorkspaceOwnership  Template = "workspace_ownership"
+	TemplateTaskReminder        Template = "task_reminder"
+	TemplateTaskDigest          Template = "task_digest"
+)
+
+type PasswordResetData struct {
+	Name      string
+	ResetURL  string
+	ExpiresIn string
+}
+
+type PasswordChangedData struct {
+	Name     string
+	ResetURL string
+}
+
+type VerifyEmailData struct {
+	Name      string
+	VerifyURL string
+	ExpiresIn string
+}
+
+type EmailChangeData struct {
+	Name       string
+	NewEmail   string
+	ConfirmURL string
+	ExpiresIn  string
+}
+
+type MagicLinkData struct {
+	Name      string
+	LoginURL  string
+	ExpiresIn string
+}
+
+type WorkspaceInvitationData struct {
+	InviterName   string
+	WorkspaceName string
+	Role          string
+	AcceptURL     string
+	ExpiresIn     string
+}
+
+// WorkspaceOwnershipData is sent to both sides of an ownership transfer.
+// NewOwner tells the two messages apart. AccountDeleted marks a transfer made
+// while the previous owner deleted their account; only the new owner is
+// told about those.
+type WorkspaceOwnershipData struct {
+	Name              string
+	WorkspaceName     string
+	PreviousOwnerName string
+	NewOwnerName      string
+	NewOwner          bool
+	AccountDeleted    bool
+}
+
+type TaskReminderData struct {
+	Name        string
+	TaskTitle   string
+	ProjectName string
+	DueAt       string
+	AppURL      string
+}
+
+type TaskDigestData struct {
+	Name     string
+	Overdue  []DigestTask
+	Upcoming []DigestTask
+	AppURL   string
+}
+
+type DigestTask struct {
+	Title       string
+	ProjectName string
+	Due         string
+}
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+)
+
+// Render builds a message for to from the named template.
+func Render(to string, tmpl Template, data any) (Message, error) {
+	name := string(tmpl)
+
+	var subject bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&subject, name+":subject", data); err != nil {
+		return Message{}, fmt.Errorf("render %s subject: %w", name, err)
+	}
+
+	var text bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+":text", data); err != nil {
+		return Message{}, fmt.Errorf("render %s text: %w", name, err)
+	}
+
+	var html bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&html, name+":html", data); err != nil {
+		return Message{}, fmt.Errorf("render %s html: %w", name, err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
@@ -5,17 +5,21 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/pat"
+	"github.con/falasefemi2/taskflow/api/internal/token"
 )
 
 type contextKey string
 
 const (
-	UserIDKey   contextKey = "userID"
-	UserRoleKey contextKey = "userRole"
+	UserIDKey    contextKey = "userID"
+	UserRoleKey  contextKey = "userRole"
+	SessionIDKey contextKey = "sessionID"
+	ScopesKey    contextKey = "scopes"
 )
 
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+func AuthMiddleware(tokens *token.Service, pats *pat.Service, queries *db.Queries) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// 1. Get token from cookie first
@@ -30,32 +34,66 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 				cookie = &http.Cookie{Value: strings.TrimPrefix(authHeader, "Bearer ")}
 			}
 
-			// 2. Parse and validate token
-			token, err := jwt.Parse(cookie.Value, func(t *jwt.Token) (interface{}, error) {
-				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
+			// Personal access tokens carry their scopes instead of a session
+			if pat.IsToken(cookie.Value) {
+				accessToken, err := pats.Authenticate(r.Context(), cookie.Value)
+				if err != nil {
+					http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+					return
 				}
-				return []byte(jwtSecret), nil
-			})
-			if err != nil || !token.Valid {
+
+				ctx := context.WithValue(r.Context(), UserIDKey, accessToken.UserID.String())
+				ctx = context.WithValue(ctx, ScopesKey, accessToken.Scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// 2. Parse and validate token
+			claims, err := tokens.Parse(cookie.Value, token.TypeAccess)
+			if err != nil {
 				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
 				return
 			}
 
-			// 3. Extract claims and put in context
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok {
+			// 3. Reject tokens revoked since issue or of disabled accounts
+			userID, err := claims.UserID()
+			if err != nil {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			state, err := queries.GetUserAuthState(r.Context(), userID)
+			if err != nil || state.TokenVersion != claims.Version || state.Status == "suspended" || state.Status == "deleted" {
 				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), UserIDKey, claims["user_id"])
-			ctx = context.WithValue(ctx, UserRoleKey, claims["role"])
+			// 4. Put claims in context. The role comes from the database
+			// rather than the token, so RequireRole works for tokens issued
+			// without a role claim and a changed role applies at once.
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.Subject)
+			ctx = context.WithValue(ctx, UserRoleKey, state.Role)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// OptionalAuth authenticates requests that carry credentials, rejecting bad
+// ones as AuthMiddleware does, and lets anonymous requests through.
+// Handlers tell the two apart with GetUserID.
+func OptionalAuth(tokens *token.Service, pats *pat.Service, queries *db.Queries) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := AuthMiddleware(tokens, pats, queries)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Cookie("access_token"); err != nil && r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
 func GetUserID(r *http.Request) (string, bool) {
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	return userID, ok
@@ -65,3 +103,15 @@ func GetUserRole(r *http.Request) (string, bool) {
 	role, ok := r.Context().Value(UserRoleKey).(string)
 	return role, ok
 }
+
+func GetSessionID(r *http.Request) (string, bool) {
+	sessionID, ok := r.Context().Value(SessionIDKey).(string)
+	return sessionID, ok
+}
+
+// GetScopes returns the scopes of the personal access token the request was
+// made with. ok is false for requests made with a session.
+func GetScopes(r *http.Request) ([]string, bool) {
+	scopes, ok := r.Context().Value(ScopesKey).([]string)
+	return scopes, ok
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
+)
+
+// Resources loaded from the URL by the Load* middlewares.
+const (
+	WorkspaceKey contextKey = "workspace"
+	MemberKey    contextKey = "workspaceMember"
+	ProjectKey   contextKey = "project"
+)
+
+// LoadWorkspace loads the workspace named by the workspaceID URL parameter
+// and the caller's membership of it, and puts both in the request context.
+// Non-members are turned away here, so handlers below only need to check
+// the action they perform. It must run after AuthMiddleware.
+func LoadWorkspace(queries *db.Queries) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := currentUserID(r)
+			if !ok {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+
+			workspaceID, err := uuid.Parse(chi.URLParam(r, "workspaceID"))
+			if err != nil {
+				http.Error(w, `{"error":"invalid workspace id"}`, http.StatusBadRequest)
+				return
+			}
+
+			ws, err := queries.GetWorkspaceByID(r.Context(), workspaceID)
+			if err != nil {
+				http.Error(w, `{"error":"workspace not found"}`, http.StatusNotFound)
+				return
+			}
+
+			member, err := queries.GetWorkspaceMember(r.Context(), db.GetWorkspaceMemberParams{
+				WorkspaceID: ws.ID,
+				UserID:      userID,
+			})
+			if err != nil {
+				http.Error(w, `{"error":"access denied"}`, http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), WorkspaceKey, ws)
+			ctx = context.WithValue(ctx, MemberKey, member)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// LoadProject loads the project named by the projectID URL parameter into
+// the request context. The project must belong to the workspace loaded by
+// LoadWorkspace, and the caller must be allowed to read it.
+func LoadProject(queries *db.Queries, policy *authz.Authorizer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ws, ok := GetWorkspace(r)
+			if !ok {
+				http.Error(w, `{"error":"workspace not loaded"}`, http.StatusInternalServerError)
+				return
+			}
+
+			projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+			if err != nil {
+				http.Error(w, `{"error":"invalid project id"}`, http.StatusBadRequest)
+				return
+			}
+
+			project, err := queries.GetProjectByID(r.Context(), projectID)
+			if err != nil || project.WorkspaceID != ws.ID {
+				http.Error(w, `{"error":"project not found"}`, http.StatusNotFound)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ProjectKey, project)
+			r = r.WithContext(ctx)
+			if !authorized(w, r, policy, authz.ProjectRead) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authorize only lets requests through if the caller may perform action on
+// the deepest resource loaded so far: the project if LoadProject ran,
+// otherwise the workspace.
+func Authorize(policy *authz.Authorizer, action authz.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if authorized(w, r, policy, action) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func authorized(w http.ResponseWriter, r *http.Request, policy *authz.Authorizer, action authz.Action) bool {
+	userID, ok := currentUserID(r)
+	if !ok {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return false
+	}
+
+	ws, ok := GetWorkspace(r)
+	if !ok {
+		http.Error(w, `{"error":"workspace not loaded"}`, http.StatusInternalServerError)
+		return false
+	}
+	resource := authz.Workspace(ws.ID)
+	if project, ok := GetProject(r); ok {
+		resource = authz.Project(ws.ID, project.ID)
+	}
+
+	err := policy.Authorize(r.Context(), authz.Subject{UserID: userID}, action, resource)
+	if errors.Is(err, authz.ErrForbidden) {
+		http.Error(w, `{"error":"access denied"}`, http.StatusForbidden)
+		return false
+	}
+	if err != nil {
+		http.Error(w, `{"error":"failed to check permissions"}`, http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+func currentUserID(r *http.Request) (uuid.UUID, bool) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(userID)
+	return id, err == nil
+}
+
+// CurrentWorkspace returns the workspace and the caller's membership loaded
+// by LoadWorkspace. If either is missing, which means the route was mounted
+// without LoadWorkspace, it writes an error response and returns false.
+func CurrentWorkspace(w http.ResponseWriter, r *http.Request) (db.Workspace, db.WorkspaceMember, bool) {
+	ws, wsOK := GetWorkspace(r)
+	member, memberOK := GetMember(r)
+	if !wsOK || !memberOK {
+		http.Error(w, `{"error":"workspace not loaded"}`, http.StatusInternalServerError)
+		return db.Workspace{}, db.WorkspaceMember{}, false
+	}
+	return ws, member, true
+}
+
+// CurrentProject returns the project loaded by LoadProject. If it is
+// missing, it writes an error response and returns false.
+func CurrentProject(w http.ResponseWriter, r *http.Request) (db.Project, bool) {
+	project, ok := GetProject(r)
+	if !ok {
+		http.Error(w, `{"error":"project not loaded"}`, http.StatusInternalServerError)
+		return db.Project{}, false
+	}
+	return project, true
+}
+
+func GetWorkspace(r *http.Request) (db.Workspace, bool) {
+	ws, ok := r.Context().Value(WorkspaceKey).(db.Workspace)
+	return ws, ok
+}
+
+// GetMember returns the caller's membership of the workspace loaded by
+// LoadWorkspace.
+func GetMember(r *http.Request) (db.WorkspaceMember, bool) {
+	member, ok := r.Context().Value(MemberKey).(db.WorkspaceMember)
+	return member, ok
+}
+
+func GetProject(r *http.Request) (db.Project, bool) {
+	project, ok := r.Context().Value(ProjectKey).(db.Project)
+	return project, ok
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+)
+
+// RequireRole only lets requests through from users whose global role is one
+// of roles. AuthMiddleware reads the role from the database on every request,
+// so RequireRole must run after it and a role change applies at once.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := GetUserRole(r)
+			if !ok || !slices.Contains(roles, role) {
+				http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
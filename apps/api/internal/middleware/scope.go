@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.con/falasefemi2/taskflow/api/internal/pat"
+)
+
+// RequireScope rejects requests made with a personal access token that does
+// not grant scope. Session requests are not restricted by scopes.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scopes, ok := GetScopes(r); ok && !pat.Allows(scopes, scope) {
+				http.Error(w, `{"error":"insufficient scope"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects requests made with a personal access token. It
+// guards account and credential management, which no scope grants.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetScopes(r); ok {
+			http.Error(w, `{"error":"this endpoint requires a session"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,415 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.con/falasefemi2/taskflow/api/internal/config"
+)
+
+const (
+	testClientID     = "taskflow"
+	testClientSecret = "s3cret"
+	testRedirectURL  = "http://localhost:8080/api/v1/auth/oidc/mock/callback"
+)
+
+// mockProvider is a minimal OpenID provider that serves discovery, JWKS, an
+// authorization endpoint that approves every request, and a token endpoint
+// that checks PKCE.
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu     sync.Mutex
+	kid    string
+	key    *rsa.PrivateKey
+	codes  map[string]authRequest
+	claims jwt.MapClaims // extra or overriding ID token claims
+	// signer, when set, signs ID tokens instead of the published key.
+	signer *rsa.PrivateKey
+}
+
+type authRequest struct {
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	m := &mockProvider{t: t, codes: map[string]authRequest{}}
+	m.rotateKey("key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) issuer() string {
+	return m.server.URL
+}
+
+func (m *mockProvider) rotateKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		m.t.Fatalf("generate key: %v", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kid, m.key = kid, key
+}
+
+func (m *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 m.issuer(),
+		"authorization_endpoint": m.issuer() + "/authorize",
+		"token_endpoint":         m.issuer() + "/token",
+		"jwks_uri":               m.issuer() + "/jwks",
+	})
+}
+
+func (m *mockProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != testClientID || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	code := "code-" + q.Get("state")
+	m.mu.Lock()
+	m.codes[code] = authRequest{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	m.mu.Unlock()
+
+	http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{
+		"code":  {code},
+		"state": {q.Get("state")},
+	}.Encode(), http.StatusFound)
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != testClientID || secret != testClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	m.mu.Lock()
+	req, ok := m.codes[r.FormValue("code")]
+	delete(m.codes, r.FormValue("code"))
+	m.mu.Unlock()
+	if !ok || Challenge(r.FormValue("code_verifier")) != req.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     m.idToken(req.nonce),
+	})
+}
+
+func (m *mockProvider) idToken(nonce string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.issuer(),
+		"sub":            "user-123",
+		"aud":            testClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          "Ada@Example.com",
+		"email_verified": "true",
+		"name":           "Ada Lovelace",
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = m.kid
+	key := m.key
+	if m.signer != nil {
+		key = m.signer
+	}
+	raw, err := tok.SignedString(key)
+	if err != nil {
+		m.t.Fatalf("sign id token: %v", err)
+	}
+	return raw
+}
+
+func (m *mockProvider) newProvider() *Provider {
+	return NewProvider(config.OIDCProviderConfig{
+		Name:         "mock",
+		IssuerURL:    m.issuer(),
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		Scopes:       []string{"openid", "email", "profile"},
+		RedirectURL:  testRedirectURL,
+	}, m.server.Client())
+}
+
+// authorizeCode runs the browser leg of the flow and returns the code.
+func (m *mockProvider) authorizeCode(t *testing.T, p *Provider, state, nonce, verifier string) string {
+	t.Helper()
+
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, Challenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	client := m.server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	if got := loc.Query().Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	return loc.Query().Get("code")
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.newProvider()
+	ctx := context.Background()
+
+	code := m.authorizeCode(t, p, "state-1", "nonce-1", "verifier-1")
+
+	tok, err := p.Exchange(ctx, code, "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	id, err := p.VerifyIDToken(ctx, tok.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if id.Subject != "user-123" || id.Email != "ada@example.com" || !id.EmailVerified || id.Name != "Ada Lovelace" {
+		t.Fatalf("unexpected identity: %+v", id)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.newProvider()
+
+	code := m.authorizeCode(t, p, "state-1", "nonce-1", "verifier-1")
+
+	if _, err := p.Exchange(context.Background(), code, "another-verifier"); err == nil {
+		t.Fatal("Exchange succeeded with the wrong PKCE verifier")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		nonce string
+		// token builds the raw ID token from the provider.
+		token func(m *mockProvider) string
+	}{
+		{
+			name:  "wrong nonce",
+			nonce: "expected",
+			token: func(m *mockProvider) string { return m.idToken("other") },
+		},
+		{
+			name:  "wrong audience",
+			nonce: "n",
+			token: func(m *mockProvider) string {
+				m.claims = jwt.MapClaims{"aud": "someone-else"}
+				return m.idToken("n")
+			},
+		},
+		{
+			name:  "wrong issuer",
+			nonce: "n",
+			token: func(m *mockProvider) string {
+				m.claims = jwt.MapClaims{"iss": "https://evil.example.com"}
+				return m.idToken("n")
+			},
+		},
+		{
+			name:  "expired",
+			nonce: "n",
+			token: func(m *mockProvider) string {
+				m.claims = jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}
+				return m.idToken("n")
+			},
+		},
+		{
+			name:  "other authorized party",
+			nonce: "n",
+			token: func(m *mockProvider) string {
+				m.claims = jwt.MapClaims{"azp": "someone-else"}
+				return m.idToken("n")
+			},
+		},
+		{
+			name:  "signed by unknown key",
+			nonce: "n",
+			token: func(m *mockProvider) string {
+				m.signer = otherKey
+				return m.idToken("n")
+			},
+		},
+		{
+			name:  "unsigned",
+			nonce: "n",
+			token: func(m *mockProvider) string {
+				raw, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+					"iss":   m.issuer(),
+					"sub":   "user-123",
+					"aud":   testClientID,
+					"exp":   time.Now().Add(time.Minute).Unix(),
+					"nonce": "n",
+				}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				return raw
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			p := m.newProvider()
+
+			_, err := p.VerifyIDToken(context.Background(), tt.token(m), tt.nonce)
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAfterKeyRotation(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.newProvider()
+	ctx := context.Background()
+
+	if _, err := p.VerifyIDToken(ctx, m.idToken("n"), "n"); err != nil {
+		t.Fatalf("VerifyIDToken before rotation: %v", err)
+	}
+
+	m.rotateKey("key-2")
+	// Pretend the cached key set is old enough to be refetched.
+	p.keys.fetchedAt = time.Now().Add(-minRefreshInterval)
+
+	if _, err := p.VerifyIDToken(ctx, m.idToken("n"), "n"); err != nil {
+		t.Fatalf("VerifyIDToken after rotation: %v", err)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	p := NewProvider(config.OIDCProviderConfig{
+		Name:        "mock",
+		IssuerURL:   m.issuer() + "/",
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+	}, m.server.Client())
+	if _, err := p.Metadata(context.Background()); err != nil {
+		t.Fatalf("trailing slash should match: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.issuer(),
+			"authorization_endpoint": m.issuer() + "/authorize",
+			"token_endpoint":         m.issuer() + "/token",
+			"jwks_uri":               m.issuer() + "/jwks",
+		})
+	}))
+	defer srv.Close()
+
+	p = NewProvider(config.OIDCProviderConfig{Name: "spoof", IssuerURL: srv.URL}, srv.Client())
+	if _, err := p.Metadata(context.Background()); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("err = %v, want issuer mismatch", err)
+	}
+}
+
+func TestState(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	st := State{
+		Provider:  "mock",
+		State:     "s",
+		Nonce:     "n",
+		Verifier:  "v",
+		Redirect:  "/projects",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}
+
+	raw, err := EncodeState(secret, st)
+	if err != nil {
+		t.Fatalf("EncodeState: %v", err)
+	}
+	got, err := DecodeState(secret, raw)
+	if err != nil || got != st {
+		t.Fatalf("DecodeState = %+v, %v; want %+v", got, err, st)
+	}
+
+	if _, err := DecodeState("another-secret-another-secret-xx", raw); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("wrong secret: err = %v", err)
+	}
+
+	payload, sig, _ := strings.Cut(raw, ".")
+	if _, err := DecodeState(secret, payload+"x."+sig); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("tampered payload: err = %v", err)
+	}
+
+	st.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	expired, _ := EncodeState(secret, st)
+	if _, err := DecodeState(secret, expired); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expired state: err = %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidState = errors.New("invalid or expired login state")
+
+// State is what the login redirect hands to the callback. It travels in a
+// signed cookie so no server-side storage is needed.
+type State struct {
+	Provider  string `json:"p"`
+	State     string `json:"s"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"`
+	Redirect  string `json:"r,omitempty"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// EncodeState serialises st and signs it with secret.
+func EncodeState(secret string, st State) (string, error) {
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + sign(secret, encoded), nil
+}
+
+// DecodeState verifies the signature and expiry of raw and returns the state.
+func DecodeState(secret, raw string) (State, error) {
+	encoded, sig, ok := strings.Cut(raw, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(sign(secret, encoded))) {
+		return State{}, ErrInvalidState
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return State{}, ErrInvalidState
+	}
+	var st State
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return State{}, ErrInvalidState
+	}
+	if time.Now().Unix() > st.ExpiresAt {
+		return State{}, ErrInvalidState
+	}
+	return st, nil
+}
+
+func sign(secret, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
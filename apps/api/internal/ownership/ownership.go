@@ -0,0 +1,164 @@
+// Package ownership hands a workspace over from one member to another. It is
+// shared by the workspace transfer endpoint and account deletion, and runs on
+// queries bound to the caller's transaction so the transfer commits together
+// with whatever else the caller does.
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+)
+
+var (
+	ErrNewOwnerNotMember = errors.New("new owner must be a member of the workspace")
+	ErrAlreadyOwner      = errors.New("user already owns the workspace")
+	ErrNotOwner          = errors.New("only a workspace owner can transfer ownership")
+)
+
+// Transfer is a completed change of ownership.
+type Transfer struct {
+	Workspace       db.Workspace
+	PreviousOwnerID uuid.UUID
+	NewOwnerID      uuid.UUID
+}
+
+// Move makes toUserID, who must already be a member, an owner of the
+// workspace in place of fromUserID, who is demoted to admin. A workspace can
+// have several members with the owner role; owner_id follows the transfer
+// only when it pointed at fromUserID. The change is recorded in
+// activity_logs. q should be bound to a transaction.
+//
+// fromUserID's role is checked again once the workspace row is locked, so a
+// transfer that committed in the meantime cannot be followed by a second one
+// from the same, by then demoted, owner.
+func Move(ctx context.Context, q *db.Queries, workspaceID, fromUserID, toUserID uuid.UUID) (Transfer, error) {
+	locked, err := q.GetWorkspaceForUpdate(ctx, workspaceID)
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	from, err := q.GetWorkspaceMember(ctx, db.GetWorkspaceMemberParams{
+		WorkspaceID: workspaceID,
+		UserID:      fromUserID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Transfer{}, ErrNotOwner
+	} else if err != nil {
+		return Transfer{}, err
+	}
+	if from.Role != authz.RoleOwner {
+		return Transfer{}, ErrNotOwner
+	}
+
+	if toUserID == fromUserID {
+		return Transfer{}, ErrAlreadyOwner
+	}
+
+	if _, err := q.UpdateWorkspaceMemberRole(ctx, db.UpdateWorkspaceMemberRoleParams{
+		WorkspaceID: workspaceID,
+		UserID:      toUserID,
+		Role:        authz.RoleOwner,
+	}); errors.Is(err, pgx.ErrNoRows) {
+		return Transfer{}, ErrNewOwnerNotMember
+	} else if err != nil {
+		return Transfer{}, err
+	}
+
+	if _, err := q.UpdateWorkspaceMemberRole(ctx, db.UpdateWorkspaceMemberRoleParams{
+		WorkspaceID: workspaceID,
+		UserID:      fromUserID,
+		Role:        authz.RoleAdmin,
+	}); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Transfer{}, err
+	}
+
+	if locked.OwnerID == fromUserID {
+		if err := q.SetWorkspaceOwner(ctx, db.SetWorkspaceOwnerParams{
+			ID:      workspaceID,
+			OwnerID: toUserID,
+		}); err != nil {
+			return Transfer{}, err
+		}
+	}
+
+	metadata, _ := json.Marshal(map[string]string{
+		"previous_owner_id": fromUserID.String(),
+		"new_owner_id":      toUserID.String(),
+	})
+	if _, err := q.CreateActivityLog(ctx, db.CreateActivityLogParams{
+		WorkspaceID: pgtype.UUID{Bytes: workspaceID, Valid: true},
+		UserID:      fromUserID,
+		Action:      "workspace.ownership_transferred",
+		EntityType:  "workspace",
+		EntityID:    workspaceID,
+		Metadata:    metadata,
+	}); err != nil {
+		return Transfer{}, err
+	}
+
+	ws, err := q.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return Transfer{}, err
+	}
+	return Transfer{Workspace: ws, PreviousOwnerID: fromUserID, NewOwnerID: toUserID}, nil
+}
+
+// Notify emails both sides of a committed transfer.
+func Notify(ctx context.Context, q *db.Queries, mail mailer.Mailer, t Transfer) error {
+	previous, err := q.GetUserByID(ctx, t.PreviousOwnerID)
+	if err != nil {
+		return err
+	}
+	next, err := q.GetUserByID(ctx, t.NewOwnerID)
+	if err != nil {
+		return err
+	}
+
+	for _, to := range []db.User{next, previous} {
+		if err := send(ctx, mail, to, mailer.WorkspaceOwnershipData{
+			WorkspaceName:     t.Workspace.Name,
+			PreviousOwnerName: previous.Name,
+			NewOwnerName:      next.Name,
+			NewOwner:          to.ID == next.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyAccountDeleted emails the new owner of a workspace handed over while
+// its previous owner deleted their account. previousOwnerName is passed in
+// because the previous owner's row is already gone or scrubbed by then.
+func NotifyAccountDeleted(ctx context.Context, q *db.Queries, mail mailer.Mailer, t Transfer, previousOwnerName string) error {
+	next, err := q.GetUserByID(ctx, t.NewOwnerID)
+	if err != nil {
+		return err
+	}
+
+	return send(ctx, mail, next, mailer.WorkspaceOwnershipData{
+		WorkspaceName:     t.Workspace.Name,
+		PreviousOwnerName: previousOwnerName,
+		NewOwnerName:      next.Name,
+		NewOwner:          true,
+		AccountDeleted:    true,
+	})
+}
+
+func send(ctx context.Context, mail mailer.Mailer, to db.User, data mailer.WorkspaceOwnershipData) error {
+	data.Name = to.Name
+	msg, err := mailer.Render(to.Email, mailer.TemplateWorkspaceOwnership, data)
+	if err != nil {
+		return err
+	}
+	return mail.Send(ctx, msg)
+}
@@ -0,0 +1,151 @@
+// Package password hashes and verifies user passwords and enforces the
+// password policy. New hashes use argon2id in PHC string format; bcrypt
+// hashes from before the switch are still accepted and flagged for rehash.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.con/falasefemi2/taskflow/api/internal/config"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match")
+	ErrUnknownFormat = errors.New("unknown password hash format")
+)
+
+// Params are the argon2id cost parameters. Memory is in KiB.
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+type Service struct {
+	params Params
+	policy Policy
+}
+
+func NewService(cfg config.PasswordConfig) *Service {
+	return &Service{
+		params: Params{
+			Memory:      uint32(cfg.Argon2Memory),
+			Iterations:  uint32(cfg.Argon2Iterations),
+			Parallelism: uint8(cfg.Argon2Parallelism),
+		},
+		policy: Policy{
+			MinLength:   cfg.MinLength,
+			CheckCommon: cfg.CheckCommon,
+		},
+	}
+}
+
+// Hash returns an argon2id hash of password in PHC string format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func (s *Service) Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, s.params.Iterations, s.params.Memory, s.params.Parallelism, keyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, s.params.Memory, s.params.Iterations, s.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks password against encoded. needsRehash is true when the
+// password matched but encoded uses another algorithm or older parameters,
+// so the caller should store a fresh Hash.
+func (s *Service) Verify(password, encoded string) (needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return false, ErrMismatch
+		}
+		return params != s.params || len(salt) != saltLength || len(key) != keyLength, nil
+
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) != nil {
+			return false, ErrMismatch
+		}
+		return true, nil
+
+	default:
+		return false, ErrUnknownFormat
+	}
+}
+
+// Matches reports whether password matches encoded, for callers that do not
+// upgrade hashes.
+func (s *Service) Matches(password, encoded string) bool {
+	_, err := s.Verify(password, encoded)
+	return err == nil
+}
+
+// Unusable returns a hash of a random password nobody knows, for accounts
+// that must not be able to sign in with a password until it is reset.
+func (s *Service) Unusable() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return s.Hash(base64.RawURLEncoding.EncodeToString(raw))
+}
+
+// Validate checks password against the policy. userInputs are values the
+// password must not contain, such as the user's email and name.
+func (s *Service) Validate(password string, userInputs ...string) error {
+	return s.policy.Validate(password, userInputs...)
+}
+
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	var p Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+	if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, ErrUnknownFormat
+	}
+	return p, salt, key, nil
+}
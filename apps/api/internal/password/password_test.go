@@ -0,0 +1,41 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keeps argon2id cheap enough for tests.
+var testParams = Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestVerifyBcryptNeedsRehash(t *testing.T) {
+	s := &Service{params: testParams}
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	needsRehash, err := s.Verify("correct horse battery", string(legacy))
+	if err != nil {
+		t.Fatalf("Verify with the right password = %v, want nil", err)
+	}
+	if !needsRehash {
+		t.Fatal("Verify of a bcrypt hash did not ask for a rehash")
+	}
+
+	if _, err := s.Verify("wrong horse battery", string(legacy)); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("Verify with the wrong password = %v, want ErrMismatch", err)
+	}
+
+	upgraded, err := s.Hash("correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	needsRehash, err = s.Verify("correct horse battery", upgraded)
+	if err != nil || needsRehash {
+		t.Fatalf("Verify of the upgraded hash = (%v, %v), want (false, nil)", needsRehash, err)
+	}
+}
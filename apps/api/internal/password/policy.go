@@ -0,0 +1,101 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLength bounds the work a single hash can cost.
+const maxLength = 128
+
+// minUserInputLength keeps very short names from ruling out too many
+// passwords.
+const minUserInputLength = 4
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = parseList(commonPasswordList)
+
+// PolicyError says why a password was rejected. Its message is meant to be
+// shown to the user.
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Reason
+}
+
+type Policy struct {
+	MinLength int
+	// CheckCommon rejects passwords on the bundled list of common and
+	// breached passwords.
+	CheckCommon bool
+}
+
+// Validate returns a *PolicyError if password breaks the policy.
+func (p Policy) Validate(password string, userInputs ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return &PolicyError{Reason: fmt.Sprintf("password must be at least %d characters", p.MinLength)}
+	}
+	if length > maxLength {
+		return &PolicyError{Reason: fmt.Sprintf("password must be at most %d characters", maxLength)}
+	}
+
+	lower := strings.ToLower(password)
+	for _, input := range expandUserInputs(userInputs) {
+		if strings.Contains(lower, input) {
+			return &PolicyError{Reason: "password must not contain your name or email"}
+		}
+	}
+
+	if p.CheckCommon {
+		if _, ok := commonPasswords[lower]; ok {
+			return &PolicyError{Reason: "password is too common, choose another"}
+		}
+	}
+	return nil
+}
+
+// expandUserInputs returns the lowercased inputs plus the local part of
+// emails and the parts of multi-word names, skipping any that are too short
+// to matter.
+func expandUserInputs(inputs []string) []string {
+	var out []string
+	add := func(s string) {
+		if utf8.RuneCountInString(s) >= minUserInputLength {
+			out = append(out, s)
+		}
+	}
+	for _, input := range inputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		add(input)
+		if local, _, ok := strings.Cut(input, "@"); ok {
+			add(local)
+		}
+		if fields := strings.Fields(input); len(fields) > 1 {
+			for _, f := range fields {
+				add(f)
+			}
+		}
+	}
+	return out
+}
+
+func parseList(list string) map[string]struct{} {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}
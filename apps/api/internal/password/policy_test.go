@@ -0,0 +1,59 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy := Policy{MinLength: 10, CheckCommon: true}
+	inputs := []string{"jane.doe@example.com", "Jane Doe"}
+
+	tests := []struct {
+		name     string
+		password string
+		inputs   []string
+		policy   Policy
+		want     string // empty if the password is accepted
+	}{
+		{name: "acceptable", password: "correct horse battery", want: ""},
+		{name: "exactly min length", password: "tr0ub4dor&", want: ""},
+		{name: "too short", password: "tr0ub4dor", want: "password must be at least 10 characters"},
+		{name: "length counts runes", password: "ééééééééé", want: "password must be at least 10 characters"},
+		{name: "exactly max length", password: strings.Repeat("x", maxLength), want: ""},
+		{name: "too long", password: strings.Repeat("x", maxLength+1), want: "password must be at most 128 characters"},
+		{name: "contains email", password: "my jane.doe@example.com pw", inputs: inputs, want: "password must not contain your name or email"},
+		{name: "contains email local part", password: "xx-Jane.Doe-2024", inputs: inputs, want: "password must not contain your name or email"},
+		{name: "contains name word", password: "team-jane-forever", inputs: inputs, want: "password must not contain your name or email"},
+		{name: "short inputs ignored", password: "al is my best pal", inputs: []string{"Al", "al@x.io"}, want: ""},
+		{name: "common password", password: "1234567890", want: "password is too common, choose another"},
+		{name: "common password any case", password: "PASSWORD123", policy: Policy{MinLength: 8, CheckCommon: true}, want: "password is too common, choose another"},
+		{name: "common check off", password: "1234567890", policy: Policy{MinLength: 10}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy
+			if tt.policy != (Policy{}) {
+				p = tt.policy
+			}
+
+			err := p.Validate(tt.password, tt.inputs...)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate(%q) = %v, want nil", tt.password, err)
+				}
+				return
+			}
+
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Validate(%q) = %v, want a *PolicyError", tt.password, err)
+			}
+			if policyErr.Reason != tt.want {
+				t.Fatalf("Validate(%q) = %q, want %q", tt.password, policyErr.Reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package pat implements personal access tokens: long-lived, scoped bearer
+// tokens that users create for scripts and CI. Only their hash is stored.
+package pat
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+)
+
+// Prefix marks a bearer token as a personal access token rather than a JWT,
+// and makes leaked tokens easy to find with secret scanners.
+const Prefix = "tfp_"
+
+// displayPrefixLength is how much of a token is kept in clear so users can
+// tell their tokens apart.
+const displayPrefixLength = len(Prefix) + 4
+
+// Scopes are "<resource>:<access>". A write scope also grants read.
+const (
+	ScopeUserRead        = "user:read"
+	ScopeUserWrite       = "user:write"
+	ScopeWorkspacesRead  = "workspaces:read"
+	ScopeWorkspacesWrite = "workspaces:write"
+	ScopeProjectsRead    = "projects:read"
+	ScopeProjectsWrite   = "projects:write"
+	ScopeTasksRead       = "tasks:read"
+	ScopeTasksWrite      = "tasks:write"
+)
+
+var Scopes = []string{
+	ScopeUserRead, ScopeUserWrite,
+	ScopeWorkspacesRead, ScopeWorkspacesWrite,
+	ScopeProjectsRead, ScopeProjectsWrite,
+	ScopeTasksRead, ScopeTasksWrite,
+}
+
+var (
+	ErrInvalidToken = errors.New("invalid personal access token")
+	ErrExpiredToken = errors.New("personal access token expired")
+)
+
+// ValidScope reports whether scope is one of Scopes.
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// Allows reports whether the granted scopes cover required.
+func Allows(granted []string, required string) bool {
+	if slices.Contains(granted, required) {
+		return true
+	}
+	resource, access, ok := strings.Cut(required, ":")
+	return ok && access == "read" && slices.Contains(granted, resource+":write")
+}
+
+// IsToken reports whether raw looks like a personal access token.
+func IsToken(raw string) bool {
+	return strings.HasPrefix(raw, Prefix)
+}
+
+// Generate returns a new raw token and the prefix kept for display.
+func Generate() (raw, displayPrefix string, err error) {
+	secret, err := token.GenerateOpaque(32)
+	if err != nil {
+		return "", "", err
+	}
+	raw = Prefix + secret
+	return raw, raw[:displayPrefixLength], nil
+}
+
+type Service struct {
+	queries *db.Queries
+}
+
+func NewService(queries *db.Queries) *Service {
+	return &Service{queries: queries}
+}
+
+// Authenticate looks up raw and returns the token if it is valid, not
+// expired and belongs to an account in good standing. A temporary login
+// lockout does not block tokens, so CI keeps working while someone
+// hammers the login form.
+func (s *Service) Authenticate(ctx context.Context, raw string) (db.GetPersonalAccessTokenByHashRow, error) {
+	if !IsToken(raw) {
+		return db.GetPersonalAccessTokenByHashRow{}, ErrInvalidToken
+	}
+
+	pat, err := s.queries.GetPersonalAccessTokenByHash(ctx, token.Hash(raw))
+	if err != nil {
+		return db.GetPersonalAccessTokenByHashRow{}, ErrInvalidToken
+	}
+	if pat.ExpiresAt.Valid && pat.ExpiresAt.Time.Before(time.Now()) {
+		return db.GetPersonalAccessTokenByHashRow{}, ErrExpiredToken
+	}
+	if pat.UserStatus != "active" && pat.UserStatus != "locked" {
+		return db.GetPersonalAccessTokenByHashRow{}, ErrInvalidToken
+	}
+
+	if err := s.queries.TouchPersonalAccessToken(ctx, pat.ID); err != nil {
+		slog.Error("failed to update personal access token last use", "token_id", pat.ID, "error", err)
+	}
+	return pat, nil
+}
@@ -0,0 +1,357 @@
+package project
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+const (
+	defaultColor = "#6366f1"
+	dateLayout   = "2006-01-02"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type Handler struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+func NewHandler(pool *pgxpool.Pool) *Handler {
+	return &Handler{pool: pool, queries: db.New(pool)}
+}
+
+type CreateProjectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	StartDate   string `json:"start_date"` // YYYY-MM-DD
+	DueDate     string `json:"due_date"`   // YYYY-MM-DD
+}
+
+// UpdateProjectRequest changes only the fields that are present. An empty
+// start_date or due_date clears it.
+type UpdateProjectRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Status      *string `json:"status"` // active | archived
+	Color       *string `json:"color"`
+	StartDate   *string `json:"start_date"`
+	DueDate     *string `json:"due_date"`
+}
+
+type ProjectResponse struct {
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Color       string    `json:"color"`
+	OwnerID     uuid.UUID `json:"owner_id"`
+	StartDate   *string   `json:"start_date"`
+	DueDate     *string   `json:"due_date"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type PaginatedProjectsResponse struct {
+	Data   []ProjectResponse `json:"data"`
+	Total  int64             `json:"total"`
+	Limit  int32             `json:"limit"`
+	Offset int32             `json:"offset"`
+}
+
+func toResponse(p db.Project) ProjectResponse {
+	return ProjectResponse{
+		ID:          p.ID,
+		WorkspaceID: p.WorkspaceID,
+		Name:        p.Name,
+		Description: p.Description.String,
+		Status:      p.Status,
+		Color:       p.Color.String,
+		OwnerID:     p.OwnerID,
+		StartDate:   formatDate(p.StartDate),
+		DueDate:     formatDate(p.DueDate),
+		CreatedBy:   p.CreatedBy,
+		CreatedAt:   p.CreatedAt.Time,
+		UpdatedAt:   p.UpdatedAt.Time,
+	}
+}
+
+func formatDate(d pgtype.Date) *string {
+	if !d.Valid {
+		return nil
+	}
+	s := d.Time.Format(dateLayout)
+	return &s
+}
+
+// parseDate parses a YYYY-MM-DD date. An empty string is a null date.
+func parseDate(s string) (pgtype.Date, bool) {
+	if s == "" {
+		return pgtype.Date{}, true
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return pgtype.Date{}, false
+	}
+	return pgtype.Date{Time: t, Valid: true}, true
+}
+
+// validDates reports whether start comes no later than due when both are set.
+func validDates(start, due pgtype.Date) bool {
+	return !start.Valid || !due.Valid || !start.Time.After(due.Time)
+}
+
+// CreateProject adds a project to the workspace. The caller owns it and is
+// made a project admin, so they can manage it even as a plain workspace
+// member.
+func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	ws, member, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateProjectRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Name == "" {
+		utils.Error(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if req.Color == "" {
+		req.Color = defaultColor
+	}
+	if !colorPattern.MatchString(req.Color) {
+		utils.Error(w, http.StatusBadRequest, "color must be a hex value like #6366f1")
+		return
+	}
+
+	startDate, ok := parseDate(req.StartDate)
+	if !ok {
+		utils.Error(w, http.StatusBadRequest, "start_date must be a date like 2006-01-02")
+		return
+	}
+	dueDate, ok := parseDate(req.DueDate)
+	if !ok {
+		utils.Error(w, http.StatusBadRequest, "due_date must be a date like 2006-01-02")
+		return
+	}
+	if !validDates(startDate, dueDate) {
+		utils.Error(w, http.StatusBadRequest, "start_date must not be after due_date")
+		return
+	}
+
+	var project db.Project
+	err := database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		var err error
+		project, err = q.CreateProject(r.Context(), db.CreateProjectParams{
+			WorkspaceID: ws.ID,
+			Name:        req.Name,
+			Description: pgtype.Text{
+				String: req.Description,
+				Valid:  req.Description != "",
+			},
+			Status:    "active",
+			Color:     pgtype.Text{String: req.Color, Valid: true},
+			OwnerID:   member.UserID,
+			StartDate: startDate,
+			DueDate:   dueDate,
+			CreatedBy: member.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.AddProjectMember(r.Context(), db.AddProjectMemberParams{
+			ProjectID: project.ID,
+			UserID:    member.UserID,
+			Role:      authz.RoleAdmin,
+		})
+		return err
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to create project")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, toResponse(project))
+}
+
+// ListProjects returns the workspace's projects, newest first.
+func (h *Handler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	ws, _, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := utils.GetPagination(r, utils.DefaultPageLimit, utils.MaxPageLimit)
+
+	projects, err := h.queries.ListProjectsByWorkspaceID(r.Context(), db.ListProjectsByWorkspaceIDParams{
+		WorkspaceID: ws.ID,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch projects")
+		return
+	}
+
+	total, err := h.queries.CountProjectsByWorkspaceID(r.Context(), ws.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch projects")
+		return
+	}
+
+	res := make([]ProjectResponse, len(projects))
+	for i, p := range projects {
+		res[i] = toResponse(p)
+	}
+
+	utils.JSON(w, http.StatusOK, PaginatedProjectsResponse{
+		Data:   res,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (h *Handler) GetProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := middleware.CurrentProject(w, r)
+	if !ok {
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, toResponse(project))
+}
+
+func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	existing, ok := middleware.CurrentProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := existing.Name
+	if req.Name != nil {
+		if *req.Name == "" {
+			utils.Error(w, http.StatusBadRequest, "name cannot be empty")
+			return
+		}
+		name = *req.Name
+	}
+
+	description := existing.Description
+	if req.Description != nil {
+		description = pgtype.Text{
+			String: *req.Description,
+			Valid:  *req.Description != "",
+		}
+	}
+
+	status := existing.Status
+	if req.Status != nil {
+		if *req.Status != "active" && *req.Status != "archived" {
+			utils.Error(w, http.StatusBadRequest, "status must be one of active, archived")
+			return
+		}
+		status = *req.Status
+	}
+
+	color := existing.Color
+	if req.Color != nil {
+		if !colorPattern.MatchString(*req.Color) {
+			utils.Error(w, http.StatusBadRequest, "color must be a hex value like #6366f1")
+			return
+		}
+		color = pgtype.Text{String: *req.Color, Valid: true}
+	}
+
+	startDate := existing.StartDate
+	if req.StartDate != nil {
+		if startDate, ok = parseDate(*req.StartDate); !ok {
+			utils.Error(w, http.StatusBadRequest, "start_date must be a date like 2006-01-02")
+			return
+		}
+	}
+	dueDate := existing.DueDate
+	if req.DueDate != nil {
+		if dueDate, ok = parseDate(*req.DueDate); !ok {
+			utils.Error(w, http.StatusBadRequest, "due_date must be a date like 2006-01-02")
+			return
+		}
+	}
+	if !validDates(startDate, dueDate) {
+		utils.Error(w, http.StatusBadRequest, "start_date must not be after due_date")
+		return
+	}
+
+	project, err := h.queries.UpdateProject(r.Context(), db.UpdateProjectParams{
+		ID:          existing.ID,
+		Name:        name,
+		Description: description,
+		Status:      status,
+		Color:       color,
+		OwnerID:     existing.OwnerID,
+		StartDate:   startDate,
+		DueDate:     dueDate,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Moved to the trash since LoadProject read it.
+		utils.Error(w, http.StatusNotFound, "project not found")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to update project")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, toResponse(project))
+}
+
+// DeleteProject moves the project and its tasks to the workspace trash.
+func (h *Handler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	existing, ok := middleware.CurrentProject(w, r)
+	if !ok {
+		return
+	}
+
+	err := database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if _, err := q.TrashProject(r.Context(), existing.ID); err != nil {
+			return err
+		}
+		return q.TrashTasksByProjectID(r.Context(), existing.ID)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusNotFound, "project not found")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to delete project")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
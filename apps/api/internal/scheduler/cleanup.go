@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+)
+
+// finishedJobRetention is how long completed and dead-lettered jobs are kept
+// for inspection.
+const finishedJobRetention = 7 * 24 * time.Hour
+
+// JobCleanup deletes finished jobs from the job queue.
+func JobCleanup(queries *db.Queries) Job {
+	return Job{
+		Name:     "job_cleanup",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			deleted, err := queries.DeleteFinishedJobs(ctx, pgtype.Timestamptz{
+				Time:  time.Now().Add(-finishedJobRetention),
+				Valid: true,
+			})
+			if err != nil {
+				return err
+			}
+			slog.Info("finished jobs cleaned up", "jobs", deleted)
+			return nil
+		},
+	}
+}
+
+// TokenCleanup deletes expired refresh tokens, used or expired single-use
+// tokens, pending email changes and verification tokens past their expiry,
+// and login throttles nobody has tripped in a while.
+func TokenCleanup(queries *db.Queries, limiter *throttle.Limiter) Job {
+	return Job{
+		Name:     "token_cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			refreshTokens, err := queries.DeleteExpiredRefreshTokens(ctx)
+			if err != nil {
+				return err
+			}
+			userTokens, err := queries.DeleteExpiredUserTokens(ctx)
+			if err != nil {
+				return err
+			}
+			emailChanges, err := queries.ClearExpiredEmailChanges(ctx)
+			if err != nil {
+				return err
+			}
+			verificationTokens, err := queries.ClearExpiredVerificationTokens(ctx)
+			if err != nil {
+				return err
+			}
+			if err := limiter.Prune(ctx); err != nil {
+				return err
+			}
+
+			slog.Info("expired tokens cleaned up",
+				"refresh_tokens", refreshTokens,
+				"user_tokens", userTokens,
+				"email_changes", emailChanges,
+				"verification_tokens", verificationTokens,
+			)
+			return nil
+		},
+	}
+}
+
+// TrashPurge permanently deletes workspaces, projects and tasks that have
+// been in the trash for longer than retention. Deleting a row still cascades
+// to whatever it contains.
+func TrashPurge(queries *db.Queries, retention time.Duration) Job {
+	return Job{
+		Name:     "trash_purge",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			cutoff := pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true}
+
+			tasks, err := queries.PurgeDeletedTasks(ctx, cutoff)
+			if err != nil {
+				return err
+			}
+			projects, err := queries.PurgeDeletedProjects(ctx, cutoff)
+			if err != nil {
+				return err
+			}
+			workspaces, err := queries.PurgeDeletedWorkspaces(ctx, cutoff)
+			if err != nil {
+				return err
+			}
+
+			slog.Info("trash purged",
+				"tasks", tasks,
+				"projects", projects,
+				"workspaces", workspaces,
+			)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+)
+
+const (
+	// reminderLeadTime is how long before its due date a task's assignee is
+	// reminded.
+	reminderLeadTime = 24 * time.Hour
+	// digestHorizon is how far ahead the digest looks for tasks.
+	digestHorizon = 7 * 24 * time.Hour
+	// digestCadence is how often a user gets a digest. It is a little under
+	// a day so a digest does not slip later every day.
+	digestCadence = 23 * time.Hour
+	// batchSize bounds how many reminders or digests go out per run. The
+	// rest are picked up by the next run.
+	batchSize           = 200
+	maxDigestTasks      = 50
+	notificationTimeFmt = "Mon, 2 Jan 2006 15:04 MST"
+)
+
+// TaskReminders emails assignees about open tasks that fall due within the
+// next day. Each task gets one reminder per due date.
+func TaskReminders(queries *db.Queries, mail mailer.Mailer, appURL string) Job {
+	return Job{
+		Name:     "task_reminders",
+		Interval: 15 * time.Minute,
+		Run: func(ctx context.Context) error {
+			tasks, err := queries.ListTasksDueForReminder(ctx, db.ListTasksDueForReminderParams{
+				DueBefore: pgtype.Timestamptz{Time: time.Now().Add(reminderLeadTime), Valid: true},
+				RowLimit:  batchSize,
+			})
+			if err != nil {
+				return err
+			}
+
+			var errs []error
+			for _, task := range tasks {
+				msg, err := mailer.Render(task.AssigneeEmail, mailer.TemplateTaskReminder, mailer.TaskReminderData{
+					Name:        task.AssigneeName,
+					TaskTitle:   task.Title,
+					ProjectName: task.ProjectName,
+					DueAt:       task.DueDate.Time.UTC().Format(notificationTimeFmt),
+					AppURL:      appURL,
+				})
+				if err == nil {
+					err = mail.Send(ctx, msg)
+				}
+				if err == nil {
+					err = queries.MarkTaskReminderSent(ctx, task.ID)
+				}
+				if err != nil {
+					slog.Error("failed to send task reminder", "task_id", task.ID, "error", err)
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// TaskDigests emails each user with overdue or upcoming assigned tasks a
+// summary of them, at most once a day.
+func TaskDigests(queries *db.Queries, mail mailer.Mailer, appURL string) Job {
+	return Job{
+		Name:     "task_digests",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			dueBefore := pgtype.Timestamptz{Time: now.Add(digestHorizon), Valid: true}
+
+			users, err := queries.ListUsersDueDigest(ctx, db.ListUsersDueDigestParams{
+				SentBefore: pgtype.Timestamptz{Time: now.Add(-digestCadence), Valid: true},
+				DueBefore:  dueBefore,
+				RowLimit:   batchSize,
+			})
+			if err != nil {
+				return err
+			}
+
+			var errs []error
+			for _, user := range users {
+				if err := sendDigest(ctx, queries, mail, appURL, user, now, dueBefore); err != nil {
+					slog.Error("failed to send task digest", "user_id", user.ID, "error", err)
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+func sendDigest(ctx context.Context, queries *db.Queries, mail mailer.Mailer, appURL string, user db.User, now time.Time, dueBefore pgtype.Timestamptz) error {
+	tasks, err := queries.ListDigestTasksByAssignee(ctx, db.ListDigestTasksByAssigneeParams{
+		AssigneeID: pgtype.UUID{Bytes: user.ID, Valid: true},
+		DueBefore:  dueBefore,
+		RowLimit:   maxDigestTasks,
+	})
+	if err != nil {
+		return err
+	}
+
+	data := mailer.TaskDigestData{Name: user.Name, AppURL: appURL}
+	for _, task := range tasks {
+		item := mailer.DigestTask{
+			Title:       task.Title,
+			ProjectName: task.ProjectName,
+			Due:         task.DueDate.Time.UTC().Format(notificationTimeFmt),
+		}
+		if task.DueDate.Time.Before(now) {
+			data.Overdue = append(data.Overdue, item)
+		} else {
+			data.Upcoming = append(data.Upcoming, item)
+		}
+	}
+
+	if len(tasks) > 0 {
+		msg, err := mailer.Render(user.Email, mailer.TemplateTaskDigest, data)
+		if err != nil {
+			return err
+		}
+		if err := mail.Send(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return queries.MarkUserDigestSent(ctx, user.ID)
+}
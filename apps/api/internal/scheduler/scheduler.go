@@ -0,0 +1,200 @@
+// Package scheduler runs periodic maintenance jobs. Every replica runs the
+// scheduler, but a Postgres advisory lock and the scheduler_runs table make
+// sure each job runs on one replica at a time and at most once per interval.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/config"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+)
+
+// maxPollInterval caps how long a job waits between checks, so a job whose
+// last run was on another replica starts close to when it is due.
+const maxPollInterval = time.Minute
+
+// Job is a unit of periodic work. Run should return promptly once ctx is
+// cancelled.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	pool *pgxpool.Pool
+	jobs []Job
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(pool *pgxpool.Pool) *Scheduler {
+	return &Scheduler{pool: pool}
+}
+
+// Default returns a scheduler with the standard maintenance jobs registered.
+func Default(pool *pgxpool.Pool, cfg *config.Config, mail mailer.Mailer) *Scheduler {
+	queries := db.New(pool)
+	s := New(pool)
+	s.Register(TokenCleanup(queries, throttle.New(queries, throttle.DefaultPolicy)))
+	s.Register(JobCleanup(queries))
+	s.Register(TrashPurge(queries, time.Duration(cfg.Scheduler.TrashRetentionDays)*24*time.Hour))
+	s.Register(TaskReminders(queries, mail, cfg.Primary.AppURL))
+	s.Register(TaskDigests(queries, mail, cfg.Primary.AppURL))
+	return s
+}
+
+// Register adds a job. It must be called before Start.
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every registered job in its own goroutine until Stop is called.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	slog.Info("scheduler started", "jobs", len(s.jobs))
+}
+
+// Stop cancels running jobs and waits for them to return, or for ctx to be
+// done, whichever comes first.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(min(job.Interval, maxPollInterval))
+	defer ticker.Stop()
+
+	for {
+		if err := s.runIfDue(ctx, job); err != nil && ctx.Err() == nil {
+			slog.Error("scheduled job failed", "job", job.Name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runIfDue runs job if no other replica is running it and it has not started
+// within the last interval. The advisory lock is session-level and held on a
+// connection set aside for the run, so no transaction stays open while the
+// job works, and the lock goes away with the connection if the replica dies.
+func (s *Scheduler) runIfDue(ctx context.Context, job Job) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", lockKey(job.Name)).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+
+	defer unlock(ctx, conn, job.Name)
+
+	queries := db.New(conn)
+	claimed, err := queries.ClaimSchedulerRun(ctx, db.ClaimSchedulerRunParams{
+		JobName:   job.Name,
+		DueBefore: pgtype.Timestamptz{Time: time.Now().Add(-job.Interval), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if claimed == 0 {
+		return nil
+	}
+
+	started := time.Now()
+	runErr := job.Run(ctx)
+
+	// Record the outcome even if the run was cancelled by shutdown.
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if errors.Is(runErr, context.Canceled) && ctx.Err() != nil {
+		// Shutting down; give the run back so it happens again soon.
+		return queries.ReleaseSchedulerRun(recordCtx, job.Name)
+	}
+
+	if err := queries.FinishSchedulerRun(recordCtx, db.FinishSchedulerRunParams{
+		JobName:   job.Name,
+		LastError: errorText(runErr),
+	}); err != nil {
+		return err
+	}
+
+	if runErr == nil {
+		slog.Info("scheduled job finished", "job", job.Name, "duration", time.Since(started))
+	}
+	return runErr
+}
+
+// unlock releases the job's advisory lock. If that fails the connection is
+// closed instead, which drops the lock, and the pool discards it on release.
+func unlock(ctx context.Context, conn *pgxpool.Conn, name string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", lockKey(name)); err != nil {
+		slog.Error("failed to release scheduler lock", "job", name, "error", err)
+		conn.Conn().Close(ctx)
+	}
+}
+
+// lockKey maps a job name to the 64-bit key of its advisory lock.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
+
+func errorText(err error) pgtype.Text {
+	if err == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: err.Error(), Valid: true}
+}
@@ -8,26 +8,45 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
 	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/admin"
 	"github.con/falasefemi2/taskflow/api/internal/auth"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
 	"github.con/falasefemi2/taskflow/api/internal/config"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
 	mw "github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/oidc"
+	"github.con/falasefemi2/taskflow/api/internal/password"
+	"github.con/falasefemi2/taskflow/api/internal/pat"
+	"github.con/falasefemi2/taskflow/api/internal/project"
+	"github.con/falasefemi2/taskflow/api/internal/throttle"
+	"github.con/falasefemi2/taskflow/api/internal/token"
 	"github.con/falasefemi2/taskflow/api/internal/workspace"
 )
 
-func New(pool *pgxpool.Pool, cfg *config.Config) http.Handler {
+func New(pool *pgxpool.Pool, cfg *config.Config, mail mailer.Mailer, tokens *token.Service) http.Handler {
 	r := chi.NewRouter()
 	queries := db.New(pool)
-	authHandler := auth.NewHandler(queries, cfg)
-	workspaceHandler := workspace.NewHandler(queries, cfg)
+	limiter := throttle.New(queries, throttle.DefaultPolicy)
+	pats := pat.NewService(queries)
+	providers := oidc.NewRegistry(cfg.OIDC, nil)
+	passwords := password.NewService(cfg.Password)
+	policy := authz.New(authz.QueryRoles(queries))
+	authHandler := auth.NewHandler(pool, cfg, mail, tokens, limiter, providers, passwords)
+	adminHandler := admin.NewHandler(pool, limiter, passwords, authHandler)
+	workspaceHandler := workspace.NewHandler(pool, cfg, mail)
+	projectHandler := project.NewHandler(pool)
 
 	// Global middleware
 	r.Use(middleware.RequestID)
+	if cfg.Server.TrustProxy {
+		r.Use(middleware.RealIP)
+	}
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{cfg.Server.AllowedOrigin},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -41,26 +60,138 @@ func New(pool *pgxpool.Pool, cfg *config.Config) http.Handler {
 		}
 	})
 
+	r.Get("/.well-known/jwks.json", tokens.JWKSHandler)
+
 	// public
 	r.Route("/api/v1/auth", func(ar chi.Router) {
 		ar.Post("/register", authHandler.Register)
 		ar.Post("/login", authHandler.Login)
+		ar.Post("/login/mfa", authHandler.LoginMFA)
+		ar.Post("/magic-link", authHandler.RequestMagicLink)
+		ar.Post("/magic-link/consume", authHandler.ConsumeMagicLink)
 		ar.Post("/refresh", authHandler.Refresh)
 		ar.Post("/logout", authHandler.Logout)
 		ar.Post("/forgot-password", authHandler.ForgotPassword)
 		ar.Post("/reset-password", authHandler.ResetPassword)
+		ar.Post("/verify-email", authHandler.VerifyEmail)
+		ar.Post("/resend-verification", authHandler.ResendVerification)
+		ar.Post("/confirm-email-change", authHandler.ConfirmEmailChange)
+		ar.Get("/oidc/{provider}/login", authHandler.OIDCLogin)
+		ar.Get("/oidc/{provider}/callback", authHandler.OIDCCallback)
 	})
 
+	// Invitations can be accepted by a signed-in user or while signing up,
+	// but not with a personal access token
+	r.With(mw.OptionalAuth(tokens, pats, queries), mw.RequireSession).Post("/api/v1/invitations/accept", authHandler.AcceptInvitation)
+
 	// protected
 	r.Group(func(r chi.Router) {
-		r.Use(mw.AuthMiddleware(cfg.Auth.JWTSecret))
-
-		r.Get("/api/v1/auth/me", authHandler.Me)
-		r.Post("/api/v1/workspaces", workspaceHandler.CreateWorkspace)
-		r.Get("/api/v1/workspaces", workspaceHandler.ListWorkspaces)
-		r.Get("/{id}", workspaceHandler.GetWorkspace)
-		r.Put("/{id}", workspaceHandler.UpdateWorkspace)
-		r.Delete("/{id}", workspaceHandler.DeleteWorkspace)
+		r.Use(mw.AuthMiddleware(tokens, pats, queries))
+
+		r.With(mw.RequireScope(pat.ScopeUserRead)).Get("/api/v1/auth/me", authHandler.Me)
+		r.With(mw.RequireScope(pat.ScopeUserWrite)).Patch("/api/v1/auth/me", authHandler.UpdateMe)
+		r.With(mw.RequireScope(pat.ScopeUserRead)).Get("/api/v1/auth/me/preferences", authHandler.GetPreferences)
+		r.With(mw.RequireScope(pat.ScopeUserWrite)).Patch("/api/v1/auth/me/preferences", authHandler.UpdatePreferences)
+
+		// Account and credential management is not open to personal access tokens
+		r.Group(func(r chi.Router) {
+			r.Use(mw.RequireSession)
+
+			r.Delete("/api/v1/auth/me", authHandler.DeleteMe)
+			r.Post("/api/v1/auth/me/password", authHandler.ChangePassword)
+			r.Post("/api/v1/auth/me/email", authHandler.ChangeEmail)
+			r.Get("/api/v1/auth/sessions", authHandler.ListSessions)
+			r.Delete("/api/v1/auth/sessions/{id}", authHandler.RevokeSession)
+			r.Post("/api/v1/auth/sessions/revoke-others", authHandler.RevokeOtherSessions)
+			r.Post("/api/v1/auth/2fa/enroll", authHandler.EnrollTwoFactor)
+			r.Post("/api/v1/auth/2fa/confirm", authHandler.ConfirmTwoFactor)
+			r.Post("/api/v1/auth/2fa/disable", authHandler.DisableTwoFactor)
+			r.Get("/api/v1/auth/tokens", authHandler.ListAccessTokens)
+			r.Post("/api/v1/auth/tokens", authHandler.CreateAccessToken)
+			r.Delete("/api/v1/auth/tokens/{id}", authHandler.RevokeAccessToken)
+		})
+
+		r.Route("/api/v1/workspaces", func(r chi.Router) {
+			read := mw.RequireScope(pat.ScopeWorkspacesRead)
+			write := mw.RequireScope(pat.ScopeWorkspacesWrite)
+
+			r.With(write).Post("/", workspaceHandler.CreateWorkspace)
+			r.With(read).Get("/", workspaceHandler.ListWorkspaces)
+			r.With(read).Get("/by-slug/{slug}", workspaceHandler.GetWorkspaceBySlug)
+			r.With(read).Get("/trash", workspaceHandler.ListDeletedWorkspaces)
+
+			r.Route("/{workspaceID}", func(r chi.Router) {
+				// LoadWorkspace only sees live workspaces, so restoring one
+				// from the trash stays outside it.
+				r.With(write).Post("/restore", workspaceHandler.RestoreWorkspace)
+
+				r.Group(func(r chi.Router) {
+					r.Use(mw.LoadWorkspace(queries))
+
+					r.With(read).Get("/", workspaceHandler.GetWorkspace)
+					r.With(write, mw.Authorize(policy, authz.WorkspaceUpdate)).Put("/", workspaceHandler.UpdateWorkspace)
+					r.With(write, mw.Authorize(policy, authz.WorkspaceDelete)).Delete("/", workspaceHandler.DeleteWorkspace)
+					r.With(write, mw.Authorize(policy, authz.WorkspaceTransfer)).Post("/transfer", workspaceHandler.TransferOwnership)
+
+					r.Route("/members", func(r chi.Router) {
+						r.With(read, mw.Authorize(policy, authz.MembersRead)).Get("/", workspaceHandler.ListMembers)
+						r.With(write, mw.Authorize(policy, authz.MembersManage)).Post("/", workspaceHandler.AddMember)
+						r.With(write).Delete("/me", workspaceHandler.LeaveWorkspace)
+						r.With(write, mw.Authorize(policy, authz.MembersManage)).Patch("/{userID}", workspaceHandler.UpdateMemberRole)
+						r.With(write, mw.Authorize(policy, authz.MembersManage)).Delete("/{userID}", workspaceHandler.RemoveMember)
+					})
+
+					r.Route("/invitations", func(r chi.Router) {
+						r.Use(mw.Authorize(policy, authz.InvitationsManage))
+
+						r.With(read).Get("/", workspaceHandler.ListInvitations)
+						r.With(write).Post("/", workspaceHandler.CreateInvitation)
+						r.With(write).Post("/{invitationID}/resend", workspaceHandler.ResendInvitation)
+						r.With(write).Delete("/{invitationID}", workspaceHandler.RevokeInvitation)
+					})
+
+					r.Route("/projects", func(r chi.Router) {
+						read := mw.RequireScope(pat.ScopeProjectsRead)
+						write := mw.RequireScope(pat.ScopeProjectsWrite)
+
+						r.With(read, mw.Authorize(policy, authz.ProjectRead)).Get("/", projectHandler.ListProjects)
+						r.With(write, mw.Authorize(policy, authz.ProjectCreate)).Post("/", projectHandler.CreateProject)
+
+						r.Route("/{projectID}", func(r chi.Router) {
+							r.Use(mw.LoadProject(queries, policy))
+
+							r.With(read).Get("/", projectHandler.GetProject)
+							r.With(write, mw.Authorize(policy, authz.ProjectUpdate)).Patch("/", projectHandler.UpdateProject)
+							r.With(write, mw.Authorize(policy, authz.ProjectDelete)).Delete("/", projectHandler.DeleteProject)
+						})
+					})
+
+					r.Route("/trash", func(r chi.Router) {
+						r.Use(mw.Authorize(policy, authz.ProjectDelete))
+
+						r.With(read).Get("/projects", workspaceHandler.ListTrashedProjects)
+						r.With(read).Get("/tasks", workspaceHandler.ListTrashedTasks)
+						r.With(write).Post("/projects/{projectID}/restore", workspaceHandler.RestoreProject)
+						r.With(write).Post("/tasks/{taskID}/restore", workspaceHandler.RestoreTask)
+					})
+				})
+			})
+		})
+	})
+
+	// admin
+	r.Route("/api/v1/admin", func(ar chi.Router) {
+		ar.Use(mw.AuthMiddleware(tokens, pats, queries))
+		ar.Use(mw.RequireRole(admin.RoleAdmin))
+
+		ar.Get("/users", adminHandler.ListUsers)
+		ar.Get("/users/{id}", adminHandler.GetUser)
+		ar.Patch("/users/{id}/role", adminHandler.SetUserRole)
+		ar.Post("/users/{id}/suspend", adminHandler.SuspendUser)
+		ar.Post("/users/{id}/reactivate", adminHandler.ReactivateUser)
+		ar.Post("/users/{id}/unlock", adminHandler.UnlockUser)
+		ar.Post("/users/{id}/force-password-reset", adminHandler.ForcePasswordReset)
+		ar.Post("/users/{id}/revoke-sessions", adminHandler.RevokeSessions)
 	})
 
 	return r
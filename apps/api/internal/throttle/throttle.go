@@ -0,0 +1,154 @@
+// Package throttle slows down repeated attempts at sensitive actions such as
+// logging in. Counters live in Postgres so every API replica sees the same
+// limits.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+)
+
+// Actions that are throttled. They prefix every key so the same account or
+// IP address is counted separately for each action.
+const (
+	ActionLogin            = "login"
+	ActionMFA              = "mfa"
+	ActionForgotPassword   = "forgot_password"
+	ActionResetPassword    = "reset_password"
+	ActionMagicLink        = "magic_link"
+	ActionMagicLinkConsume = "magic_link_consume"
+	ActionInvitationAccept = "invitation_accept"
+)
+
+// Policy controls how quickly repeated attempts are slowed down.
+type Policy struct {
+	// FreeAttempts is the number of attempts allowed before any delay.
+	FreeAttempts int
+	// BaseDelay is the delay after the first attempt over FreeAttempts. It
+	// doubles with each further attempt up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long attempts are remembered. A key with no attempts for
+	// this long starts again from zero.
+	Window time.Duration
+}
+
+var DefaultPolicy = Policy{
+	FreeAttempts: 5,
+	BaseDelay:    time.Second,
+	MaxDelay:     15 * time.Minute,
+	Window:       time.Hour,
+}
+
+// Result describes a key after an attempt was recorded.
+type Result struct {
+	Attempts   int
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	queries *db.Queries
+	policy  Policy
+}
+
+func New(queries *db.Queries, policy Policy) *Limiter {
+	return &Limiter{queries: queries, policy: policy}
+}
+
+// AccountKey returns the key counting attempts at action against an account.
+func AccountKey(action, email string) string {
+	return action + ":account:" + email
+}
+
+// IPKey returns the key counting attempts at action from an IP address.
+func IPKey(action, ip string) string {
+	return action + ":ip:" + ip
+}
+
+// Wait returns how long the caller must wait before trying again. It is zero
+// when none of keys is currently delayed.
+func (l *Limiter) Wait(ctx context.Context, keys ...string) (time.Duration, error) {
+	var wait time.Duration
+	now := time.Now()
+
+	for _, key := range keys {
+		throttle, err := l.queries.GetAuthThrottle(ctx, key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if throttle.LockedUntil.Valid && throttle.LockedUntil.Time.After(now) {
+			wait = max(wait, throttle.LockedUntil.Time.Sub(now))
+		}
+	}
+	return wait, nil
+}
+
+// Record counts an attempt against key and, once the policy's free attempts
+// are used up, delays the next one.
+func (l *Limiter) Record(ctx context.Context, key string) (Result, error) {
+	now := time.Now().UTC()
+
+	throttle, err := l.queries.RecordAuthFailure(ctx, db.RecordAuthFailureParams{
+		Key:         key,
+		WindowStart: pgtype.Timestamptz{Time: now.Add(-l.policy.Window), Valid: true},
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Attempts: int(throttle.Failures)}
+	delay := l.delay(result.Attempts)
+	if delay == 0 {
+		return result, nil
+	}
+
+	if err := l.queries.SetAuthThrottleLock(ctx, db.SetAuthThrottleLockParams{
+		Key:         key,
+		LockedUntil: pgtype.Timestamptz{Time: now.Add(delay), Valid: true},
+	}); err != nil {
+		return result, err
+	}
+	result.RetryAfter = delay
+	return result, nil
+}
+
+// Reset forgets all attempts against keys.
+func (l *Limiter) Reset(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := l.queries.DeleteAuthThrottle(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune deletes keys that have had no attempts for longer than the policy
+// window and are not locked.
+func (l *Limiter) Prune(ctx context.Context) error {
+	return l.queries.DeleteStaleAuthThrottles(ctx, pgtype.Timestamptz{
+		Time:  time.Now().Add(-l.policy.Window),
+		Valid: true,
+	})
+}
+
+func (l *Limiter) delay(attempts int) time.Duration {
+	over := attempts - l.policy.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	delay := float64(l.policy.BaseDelay) * math.Pow(2, float64(over-1))
+	if delay > float64(l.policy.MaxDelay) {
+		return l.policy.MaxDelay
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,114 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.con/falasefemi2/taskflow/api/internal/config"
+)
+
+// Key is a named signing or verification key. Verification-only keys have
+// no signKey.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+func loadSigningKey(cfg config.AuthConfig) (*Key, error) {
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		secret := []byte(cfg.JWTSecret)
+		return &Key{ID: cfg.JWTKeyID, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+	case "RS256", "EdDSA":
+		pem, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read jwt private key: %w", err)
+		}
+		return parsePrivateKey(cfg.JWTKeyID, cfg.JWTAlgorithm, pem)
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", cfg.JWTAlgorithm)
+	}
+}
+
+func parsePrivateKey(kid, alg string, pem []byte) (*Key, error) {
+	switch alg {
+	case "RS256":
+		priv, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse rsa private key: %w", err)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, signKey: priv, verifyKey: &priv.PublicKey}, nil
+	default:
+		priv, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse ed25519 private key: %w", err)
+		}
+		edPriv, ok := priv.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("parse ed25519 private key: unexpected key type")
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, signKey: edPriv, verifyKey: edPriv.Public()}, nil
+	}
+}
+
+// loadVerificationKeys parses a comma-separated list of kid=path entries.
+// A PEM public key file yields an RS256 or EdDSA key; any other file content
+// is taken as an HS256 secret.
+func loadVerificationKeys(spec string) ([]*Key, error) {
+	var keys []*Key
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("invalid jwt verification key %q, want kid=path", entry)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read jwt verification key %s: %w", kid, err)
+		}
+
+		key, err := parseVerificationKey(kid, content)
+		if err != nil {
+			return nil, fmt.Errorf("jwt verification key %s: %w", kid, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func parseVerificationKey(kid string, content []byte) (*Key, error) {
+	if !strings.Contains(string(content), "-----BEGIN") {
+		secret := []byte(strings.TrimSpace(string(content)))
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("hmac secret must be at least 32 bytes")
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodHS256, verifyKey: secret}, nil
+	}
+	if pub, err := jwt.ParseRSAPublicKeyFromPEM(content); err == nil {
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: pub}, nil
+	}
+	if pub, err := jwt.ParseEdPublicKeyFromPEM(content); err == nil {
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: pub}, nil
+	}
+	return nil, fmt.Errorf("unsupported public key")
+}
+
+// publicKey returns the key's public half, or nil for symmetric keys.
+func (k *Key) publicKey() any {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return pub
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.con/falasefemi2/taskflow/api/internal/config"
+)
+
+// Type distinguishes what a signed token may be used for. Parse rejects a
+// token whose type differs from the one the caller expects.
+type Type string
+
+const (
+	TypeAccess     Type = "access"
+	TypeMFAPending Type = "mfa_pending"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrWrongType    = errors.New("unexpected token type")
+)
+
+// Claims are the claims carried by every token the service issues.
+type Claims struct {
+	Type      Type   `json:"typ"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	// Version is the user's token version when the token was issued. Access
+	// tokens from an older version are rejected.
+	Version int32 `json:"ver,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the subject as a user ID.
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// Service signs and verifies JWTs. It signs with a single active key and
+// accepts any configured key, so old tokens stay valid while keys rotate.
+type Service struct {
+	issuer   string
+	audience string
+	signing  *Key
+	keys     map[string]*Key
+}
+
+func NewService(cfg config.AuthConfig) (*Service, error) {
+	signing, err := loadSigningKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{
+		issuer:   cfg.JWTIssuer,
+		audience: cfg.JWTAudience,
+		signing:  signing,
+		keys:     map[string]*Key{signing.ID: signing},
+	}
+
+	extra, err := loadVerificationKeys(cfg.JWTVerificationKeys)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range extra {
+		if _, exists := s.keys[k.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", k.ID)
+		}
+		s.keys[k.ID] = k
+	}
+
+	return s, nil
+}
+
+// Issue signs claims with the active key. Issuer, audience, issued-at,
+// expiry and token ID are filled in by the service.
+func (s *Service) Issue(claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now().UTC()
+	claims.Issuer = s.issuer
+	claims.Audience = jwt.ClaimStrings{s.audience}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	claims.ID = uuid.NewString()
+
+	t := jwt.NewWithClaims(s.signing.Method, claims)
+	t.Header["kid"] = s.signing.ID
+	return t.SignedString(s.signing.signKey)
+}
+
+// Parse verifies raw and returns its claims if it is a valid, unexpired
+// token of type typ.
+func (s *Service) Parse(raw string, typ Type) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, s.keyFunc,
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Type != typ {
+		return nil, ErrWrongType
+	}
+	return claims, nil
+}
+
+func (s *Service) keyFunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// GenerateOpaque returns a random URL-safe token of size bytes of entropy.
+func GenerateOpaque(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the hex SHA-256 of an opaque token, which is what gets
+// stored in the database.
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
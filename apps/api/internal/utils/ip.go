@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the caller's IP address from r.RemoteAddr. When the server
+// runs behind a trusted proxy, the RealIP middleware has already rewritten
+// RemoteAddr from the forwarding headers.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Page sizes for list endpoints that do not pick their own.
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// GetPagination reads the limit and offset query parameters. A missing or
+// invalid limit becomes defaultLimit, a larger one is capped at maxLimit,
+// and a missing or invalid offset is 0.
+func GetPagination(r *http.Request, defaultLimit, maxLimit int) (int32, int32) {
+	limit := defaultLimit
+	offset := 0
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			if parsed > maxLimit {
+				parsed = maxLimit
+			}
+			limit = parsed
+		}
+	}
+
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	return int32(limit), int32(offset)
+}
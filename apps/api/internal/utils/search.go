@@ -0,0 +1,10 @@
+package utils
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// LikePattern escapes LIKE wildcards so a search for "50%" matches literally.
+func LikePattern(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -1,17 +1,128 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"regexp"
 	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	MinSlugLength = 2
+	MaxSlugLength = 50
+
+	// hashSlugLength is the length of the slug made for a name with no
+	// characters that can be kept.
+	hashSlugLength = 8
+)
+
+var (
+	ErrSlugLength   = errors.New("slug must be between 2 and 50 characters")
+	ErrSlugFormat   = errors.New("slug may only contain lowercase letters, digits and single hyphens between them")
+	ErrSlugReserved = errors.New("slug is reserved")
 )
 
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// reservedSlugs would clash with app routes or be confusing as a workspace
+// address.
+var reservedSlugs = map[string]bool{
+	"about": true, "account": true, "admin": true, "api": true, "app": true,
+	"assets": true, "auth": true, "billing": true, "by-slug": true,
+	"dashboard": true, "help": true, "invitations": true, "login": true,
+	"logout": true, "mail": true, "me": true, "members": true, "new": true,
+	"null": true, "projects": true, "register": true, "settings": true,
+	"signup": true, "static": true, "support": true, "tasks": true,
+	"undefined": true, "workspaces": true, "www": true,
+}
+
+// transliterations covers letters that do not decompose into an ASCII
+// letter plus accents, including the Greek and Cyrillic alphabets.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l",
+	'þ': "th", 'ı': "i", 'ŋ': "ng", 'ħ': "h", 'ŧ': "t",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh",
+	'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y",
+	'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'є': "ye", 'і': "i",
+	'ґ': "g",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// GenerateSlug turns name into a URL-safe slug. Accents are dropped and
+// Greek and Cyrillic are transliterated; any other character separates
+// words. If nothing in name could be kept, for example for a name written
+// in Chinese or Japanese, the slug is a short hash of the name instead, so
+// the same name always gets the same slug. Only a blank name gives an empty
+// slug.
 func GenerateSlug(name string) string {
-	slug := strings.ToLower(name)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	reg := regexp.MustCompile(`[^a-z0-9-]`)
-	slug = reg.ReplaceAllString(slug, "")
-	reg2 := regexp.MustCompile(`-+`)
-	slug = reg2.ReplaceAllString(slug, "-")
-	slug = strings.Trim(slug, "-")
-	return slug
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// combining accent split off by NFKD
+		default:
+			if t, ok := transliterations[r]; ok {
+				b.WriteString(t)
+			} else {
+				b.WriteByte('-')
+			}
+		}
+	}
+
+	slug := strings.Join(strings.FieldsFunc(b.String(), func(r rune) bool { return r == '-' }), "-")
+	if slug == "" {
+		return hashSlug(name)
+	}
+	return TruncateSlug(slug, MaxSlugLength)
+}
+
+// hashSlug returns the first hashSlugLength hex digits of the SHA-256 of
+// the trimmed, normalized name, or "" for a blank name.
+func hashSlug(name string) string {
+	name = norm.NFC.String(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:hashSlugLength]
+}
+
+// TruncateSlug shortens slug to at most n bytes without leaving a trailing
+// hyphen.
+func TruncateSlug(slug string, n int) string {
+	if len(slug) > n {
+		slug = slug[:n]
+	}
+	return strings.TrimRight(slug, "-")
+}
+
+// ValidateSlug checks a slug chosen by a user.
+func ValidateSlug(slug string) error {
+	if len(slug) < MinSlugLength || len(slug) > MaxSlugLength {
+		return ErrSlugLength
+	}
+	if !slugPattern.MatchString(slug) {
+		return ErrSlugFormat
+	}
+	if IsReservedSlug(slug) {
+		return ErrSlugReserved
+	}
+	return nil
+}
+
+func IsReservedSlug(slug string) bool {
+	return reservedSlugs[slug]
 }
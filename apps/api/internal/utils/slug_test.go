@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Acme Corp", "acme-corp"},
+		{"  Acme   Corp  ", "acme-corp"},
+		{"Acme_Corp!!", "acme-corp"},
+		{"Team 42", "team-42"},
+		{"Café Crème", "cafe-creme"},
+		{"Straße", "strasse"},
+		{"Ærø", "aero"},
+		{"Привет мир", "privet-mir"},
+		{"Αθήνα", "athina"},
+		{"ｆｕｌｌｗｉｄｔｈ", "fullwidth"},
+		{"開発 Team", "team"},
+		{"", ""},
+		{"   ", ""},
+		{strings.Repeat("a", 60), strings.Repeat("a", MaxSlugLength)},
+		{strings.Repeat("a", 49) + " b", strings.Repeat("a", 49)},
+	}
+
+	for _, tt := range tests {
+		if got := GenerateSlug(tt.name); got != tt.want {
+			t.Errorf("GenerateSlug(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateSlugHashFallback(t *testing.T) {
+	tests := []string{"開発", "東京チーム", "개발팀", "!!!"}
+
+	seen := make(map[string]string)
+	for _, name := range tests {
+		got := GenerateSlug(name)
+		if len(got) != hashSlugLength {
+			t.Errorf("GenerateSlug(%q) = %q, want %d characters", name, got, hashSlugLength)
+		}
+		if err := ValidateSlug(got); err != nil {
+			t.Errorf("GenerateSlug(%q) = %q, which does not validate: %v", name, got, err)
+		}
+		if again := GenerateSlug(" " + name + " "); again != got {
+			t.Errorf("GenerateSlug(%q) = %q, but with surrounding spaces %q", name, got, again)
+		}
+		if other, ok := seen[got]; ok {
+			t.Errorf("GenerateSlug(%q) and GenerateSlug(%q) are both %q", name, other, got)
+		}
+		seen[got] = name
+	}
+}
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		slug string
+		want error
+	}{
+		{"acme", nil},
+		{"acme-corp", nil},
+		{"team-42", nil},
+		{"ab", nil},
+		{strings.Repeat("a", MaxSlugLength), nil},
+		{"a", ErrSlugLength},
+		{"", ErrSlugLength},
+		{strings.Repeat("a", MaxSlugLength+1), ErrSlugLength},
+		{"Acme", ErrSlugFormat},
+		{"acme corp", ErrSlugFormat},
+		{"acme--corp", ErrSlugFormat},
+		{"-acme", ErrSlugFormat},
+		{"acme-", ErrSlugFormat},
+		{"acme_corp", ErrSlugFormat},
+		{"café", ErrSlugFormat},
+		{"admin", ErrSlugReserved},
+		{"by-slug", ErrSlugReserved},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateSlug(tt.slug); !errors.Is(err, tt.want) {
+			t.Errorf("ValidateSlug(%q) = %v, want %v", tt.slug, err, tt.want)
+		}
+	}
+}
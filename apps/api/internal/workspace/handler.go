@@ -1,59 +1,84 @@
 package workspace
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
 	"github.con/falasefemi2/taskflow/api/internal/config"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
 	"github.con/falasefemi2/taskflow/api/internal/middleware"
 	"github.con/falasefemi2/taskflow/api/internal/utils"
 )
 
 type Handler struct {
+	pool    *pgxpool.Pool
 	queries *db.Queries
 	cfg     *config.Config
+	mail    mailer.Mailer
 }
 
-func NewHandler(queries *db.Queries, cfg *config.Config) *Handler {
-	return &Handler{queries: queries, cfg: cfg}
+func NewHandler(pool *pgxpool.Pool, cfg *config.Config, mail mailer.Mailer) *Handler {
+	return &Handler{pool: pool, queries: db.New(pool), cfg: cfg, mail: mail}
 }
 
 type CreateWorkspaceRequest struct {
 	Name        string `json:"name"`
+	Slug        string `json:"slug"` // generated from name if empty
 	Description string `json:"description"`
 }
 
 type UpdateWorkspaceRequest struct {
 	Name        *string `json:"name"`
+	Slug        *string `json:"slug"`
 	Description *string `json:"description"`
 	Status      *string `json:"status"` // active | archived
 }
 
 type WorkspaceResponse struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"`
-	Description string    `json:"description"`
-	OwnerID     uuid.UUID `json:"owner_id"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Description string     `json:"description"`
+	OwnerID     uuid.UUID  `json:"owner_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// MembershipResponse is a workspace as seen by one of its members.
+type MembershipResponse struct {
+	WorkspaceResponse
+	Role string `json:"role"`
 }
 
 type PaginatedWorkspacesResponse struct {
-	Data   []WorkspaceResponse `json:"data"`
-	Limit  int32               `json:"limit"`
-	Offset int32               `json:"offset"`
+	Data   []MembershipResponse `json:"data"`
+	Total  int64                `json:"total"`
+	Limit  int32                `json:"limit"`
+	Offset int32                `json:"offset"`
 }
 
+// maxCreateAttempts bounds how often CreateWorkspace retries after losing a
+// race for a generated slug.
+const maxCreateAttempts = 3
+
+// workspaceSorts are the orders ListWorkspaces accepts.
+var workspaceSorts = []string{"name", "-name", "created_at", "-created_at", "updated_at", "-updated_at"}
+
 func toResponse(ws db.Workspace) WorkspaceResponse {
-	return WorkspaceResponse{
+	res := WorkspaceResponse{
 		ID:          ws.ID,
 		Name:        ws.Name,
 		Slug:        ws.Slug,
@@ -63,33 +88,10 @@ func toResponse(ws db.Workspace) WorkspaceResponse {
 		CreatedAt:   ws.CreatedAt.Time,
 		UpdatedAt:   ws.UpdatedAt.Time,
 	}
-}
-
-func getPagination(r *http.Request) (int32, int32) {
-	const (
-		defaultLimit = 20
-		maxLimit     = 100
-	)
-
-	limit := defaultLimit
-	offset := 0
-
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
-			if parsed > maxLimit {
-				parsed = maxLimit
-			}
-			limit = parsed
-		}
-	}
-
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-			offset = parsed
-		}
+	if ws.DeletedAt.Valid {
+		res.DeletedAt = &ws.DeletedAt.Time
 	}
-
-	return int32(limit), int32(offset)
+	return res
 }
 
 func (h *Handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
@@ -104,6 +106,13 @@ func (h *Handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Slug != "" {
+		if err := utils.ValidateSlug(req.Slug); err != nil {
+			utils.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
 	userID, ok := middleware.GetUserID(r)
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "unauthorized")
@@ -116,18 +125,62 @@ func (h *Handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slug := utils.GenerateSlug(req.Name)
-
-	ws, err := h.queries.CreateWorkspace(r.Context(), db.CreateWorkspaceParams{
-		Name: req.Name,
-		Slug: slug,
-		Description: pgtype.Text{
-			String: req.Description,
-			Valid:  req.Description != "",
-		},
-		OwnerID: ownerID,
-		Status:  "active",
-	})
+	if h.cfg.Auth.RequireVerifiedEmail {
+		owner, err := h.queries.GetUserByID(r.Context(), ownerID)
+		if err != nil {
+			utils.Error(w, http.StatusUnauthorized, "user not found")
+			return
+		}
+		if !owner.IsVerified {
+			utils.Error(w, http.StatusForbidden, "email verification required")
+			return
+		}
+	}
+
+	// A generated slug can still lose a race with a workspace created at the
+	// same moment, in which case a fresh one is picked.
+	var ws db.Workspace
+	for attempt := 1; ; attempt++ {
+		err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+			slug := req.Slug
+			if slug == "" {
+				var err error
+				if slug, err = availableSlug(r.Context(), q, utils.GenerateSlug(req.Name), uuid.Nil); err != nil {
+					return err
+				}
+			} else if err := claimSlug(r.Context(), q, slug, uuid.Nil); err != nil {
+				return err
+			}
+
+			var err error
+			ws, err = q.CreateWorkspace(r.Context(), db.CreateWorkspaceParams{
+				Name: req.Name,
+				Slug: slug,
+				Description: pgtype.Text{
+					String: req.Description,
+					Valid:  req.Description != "",
+				},
+				OwnerID: ownerID,
+				Status:  "active",
+			})
+			if err != nil {
+				return err
+			}
+			_, err = q.AddWorkspaceMember(r.Context(), db.AddWorkspaceMemberParams{
+				WorkspaceID: ws.ID,
+				UserID:      ownerID,
+				Role:        authz.RoleOwner,
+			})
+			return err
+		})
+		if req.Slug != "" || attempt == maxCreateAttempts || !database.IsUniqueViolation(err) {
+			break
+		}
+	}
+	if errors.Is(err, errSlugTaken) || database.IsUniqueViolation(err) {
+		utils.Error(w, http.StatusConflict, errSlugTaken.Error())
+		return
+	}
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "failed to create workspace")
 		return
@@ -136,6 +189,10 @@ func (h *Handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
 	utils.JSON(w, http.StatusCreated, toResponse(ws))
 }
 
+// ListWorkspaces returns every workspace the caller belongs to, with their
+// role in each. It accepts status (active | archived), q to search names,
+// and sort (name, created_at or updated_at, prefixed with - for descending;
+// newest first by default).
 func (h *Handler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r)
 	if !ok {
@@ -143,88 +200,93 @@ func (h *Handler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ownerID, err := uuid.Parse(userID)
+	memberID, err := uuid.Parse(userID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "invalid user id")
 		return
 	}
 
-	limit, offset := getPagination(r)
+	query := r.URL.Query()
 
-	workspaces, err := h.queries.ListWorkspacesByOwnerID(
-		r.Context(),
-		db.ListWorkspacesByOwnerIDParams{
-			OwnerID: ownerID,
-			Limit:   limit,
-			Offset:  offset,
-		},
-	)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "failed to fetch workspaces")
+	status := query.Get("status")
+	if status != "" && status != "active" && status != "archived" {
+		utils.Error(w, http.StatusBadRequest, "status must be active or archived")
 		return
 	}
 
-	res := make([]WorkspaceResponse, len(workspaces))
-	for i, ws := range workspaces {
-		res[i] = toResponse(ws)
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = "-created_at"
 	}
-
-	utils.JSON(w, http.StatusOK, PaginatedWorkspacesResponse{
-		Data:   res,
-		Limit:  limit,
-		Offset: offset,
-	})
-}
-
-func (h *Handler) GetWorkspace(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserID(r)
-	if !ok {
-		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+	if !slices.Contains(workspaceSorts, sort) {
+		utils.Error(w, http.StatusBadRequest, "sort must be one of "+strings.Join(workspaceSorts, ", "))
 		return
 	}
 
-	ownerID, err := uuid.Parse(userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "invalid user id")
-		return
-	}
+	search := utils.LikePattern(strings.TrimSpace(query.Get("q")))
 
-	workspaceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	limit, offset := utils.GetPagination(r, utils.DefaultPageLimit, utils.MaxPageLimit)
+
+	workspaces, err := h.queries.ListWorkspacesForUser(r.Context(), db.ListWorkspacesForUserParams{
+		UserID:    memberID,
+		Status:    pgtype.Text{String: status, Valid: status != ""},
+		Search:    pgtype.Text{String: search, Valid: search != ""},
+		Sort:      sort,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
 	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "invalid workspace id")
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch workspaces")
 		return
 	}
 
-	ws, err := h.queries.GetWorkspaceByID(r.Context(), workspaceID)
+	total, err := h.queries.CountWorkspacesForUser(r.Context(), db.CountWorkspacesForUserParams{
+		UserID: memberID,
+		Status: pgtype.Text{String: status, Valid: status != ""},
+		Search: pgtype.Text{String: search, Valid: search != ""},
+	})
 	if err != nil {
-		utils.Error(w, http.StatusNotFound, "workspace not found")
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch workspaces")
 		return
 	}
 
-	if ws.OwnerID != ownerID {
-		utils.Error(w, http.StatusForbidden, "access denied")
-		return
+	res := make([]MembershipResponse, len(workspaces))
+	for i, ws := range workspaces {
+		res[i] = MembershipResponse{
+			WorkspaceResponse: toResponse(db.Workspace{
+				ID:          ws.ID,
+				Name:        ws.Name,
+				Slug:        ws.Slug,
+				Description: ws.Description,
+				OwnerID:     ws.OwnerID,
+				Status:      ws.Status,
+				CreatedAt:   ws.CreatedAt,
+				UpdatedAt:   ws.UpdatedAt,
+			}),
+			Role: ws.Role,
+		}
 	}
 
-	utils.JSON(w, http.StatusOK, toResponse(ws))
+	utils.JSON(w, http.StatusOK, PaginatedWorkspacesResponse{
+		Data:   res,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
 }
 
-func (h *Handler) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserID(r)
+func (h *Handler) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	ws, _, ok := middleware.CurrentWorkspace(w, r)
 	if !ok {
-		utils.Error(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	ownerID, err := uuid.Parse(userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "invalid user id")
-		return
-	}
+	utils.JSON(w, http.StatusOK, toResponse(ws))
+}
 
-	workspaceID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "invalid workspace id")
+func (h *Handler) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	existing, _, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
 		return
 	}
 
@@ -234,17 +296,6 @@ func (h *Handler) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	existing, err := h.queries.GetWorkspaceByID(r.Context(), workspaceID)
-	if err != nil {
-		utils.Error(w, http.StatusNotFound, "workspace not found")
-		return
-	}
-
-	if existing.OwnerID != ownerID {
-		utils.Error(w, http.StatusForbidden, "access denied")
-		return
-	}
-
 	name := existing.Name
 	if req.Name != nil {
 		name = *req.Name
@@ -263,53 +314,71 @@ func (h *Handler) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
 		status = *req.Status
 	}
 
-	ws, err := h.queries.UpdateWorkspace(r.Context(), db.UpdateWorkspaceParams{
-		ID:          workspaceID,
-		Name:        name,
-		Slug:        existing.Slug, // keep slug stable
-		Description: description,
-		OwnerID:     ownerID,
-		Status:      status,
-	})
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "failed to update workspace")
-		return
+	slug := existing.Slug
+	if req.Slug != nil && *req.Slug != existing.Slug {
+		if err := utils.ValidateSlug(*req.Slug); err != nil {
+			utils.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slug = *req.Slug
 	}
 
-	utils.JSON(w, http.StatusOK, toResponse(ws))
-}
+	var ws db.Workspace
+	err := database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if slug != existing.Slug {
+			if err := claimSlug(r.Context(), q, slug, existing.ID); err != nil {
+				return err
+			}
+			if err := recordSlugChange(r.Context(), q, existing, slug); err != nil {
+				return err
+			}
+		}
 
-func (h *Handler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.GetUserID(r)
-	if !ok {
-		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		var err error
+		ws, err = q.UpdateWorkspace(r.Context(), db.UpdateWorkspaceParams{
+			ID:          existing.ID,
+			Name:        name,
+			Slug:        slug,
+			Description: description,
+			Status:      status,
+		})
+		return err
+	})
+	if errors.Is(err, errSlugTaken) || database.IsUniqueViolation(err) {
+		utils.Error(w, http.StatusConflict, errSlugTaken.Error())
 		return
 	}
-
-	ownerID, err := uuid.Parse(userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "invalid user id")
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Trashed after LoadWorkspace read it.
+		utils.Error(w, http.StatusNotFound, "workspace not found")
 		return
 	}
-
-	workspaceID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "invalid workspace id")
+		utils.Error(w, http.StatusInternalServerError, "failed to update workspace")
 		return
 	}
 
-	existing, err := h.queries.GetWorkspaceByID(r.Context(), workspaceID)
-	if err != nil {
-		utils.Error(w, http.StatusNotFound, "workspace not found")
-		return
-	}
+	utils.JSON(w, http.StatusOK, toResponse(ws))
+}
 
-	if existing.OwnerID != ownerID {
-		utils.Error(w, http.StatusForbidden, "access denied")
+// DeleteWorkspace moves the workspace to the trash together with its
+// projects and tasks. RestoreWorkspace brings them back.
+func (h *Handler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	existing, _, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.queries.DeleteWorkspace(r.Context(), workspaceID); err != nil {
+	err := database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if _, err := q.TrashWorkspace(r.Context(), existing.ID); err != nil {
+			return err
+		}
+		if err := q.TrashProjectsByWorkspaceID(r.Context(), existing.ID); err != nil {
+			return err
+		}
+		return q.TrashTasksByWorkspaceID(r.Context(), existing.ID)
+	})
+	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "failed to delete workspace")
 		return
 	}
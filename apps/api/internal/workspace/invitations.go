@@ -0,0 +1,323 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
+	"github.con/falasefemi2/taskflow/api/internal/mailer"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/token"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+// Invitation statuses. An invitation past its expiry keeps the pending
+// status in the database and is reported as expired.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type InvitationResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"`
+	InvitedBy  *uuid.UUID `json:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type PaginatedInvitationsResponse struct {
+	Data   []InvitationResponse `json:"data"`
+	Total  int64                `json:"total"`
+	Limit  int32                `json:"limit"`
+	Offset int32                `json:"offset"`
+}
+
+func toInvitationResponse(inv db.WorkspaceInvitation) InvitationResponse {
+	res := InvitationResponse{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		Status:    inv.Status,
+		ExpiresAt: inv.ExpiresAt.Time,
+		CreatedAt: inv.CreatedAt.Time,
+	}
+	if inv.Status == InvitationPending && inv.ExpiresAt.Time.Before(time.Now()) {
+		res.Status = InvitationExpired
+	}
+	if inv.InvitedBy.Valid {
+		id := uuid.UUID(inv.InvitedBy.Bytes)
+		res.InvitedBy = &id
+	}
+	if inv.AcceptedAt.Valid {
+		res.AcceptedAt = &inv.AcceptedAt.Time
+	}
+	return res
+}
+
+// ListInvitations returns the workspace's invitations, newest first. An
+// optional status query parameter filters by pending, accepted or revoked.
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	var status pgtype.Text
+	switch s := r.URL.Query().Get("status"); s {
+	case "":
+	case InvitationPending, InvitationAccepted, InvitationRevoked:
+		status = pgtype.Text{String: s, Valid: true}
+	default:
+		utils.Error(w, http.StatusBadRequest, "status must be one of pending, accepted, revoked")
+		return
+	}
+
+	limit, offset := utils.GetPagination(r, utils.DefaultPageLimit, utils.MaxPageLimit)
+
+	invitations, err := h.queries.ListWorkspaceInvitations(r.Context(), db.ListWorkspaceInvitationsParams{
+		WorkspaceID: actor.WorkspaceID,
+		Status:      status,
+		RowLimit:    limit,
+		RowOffset:   offset,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch invitations")
+		return
+	}
+
+	total, err := h.queries.CountWorkspaceInvitations(r.Context(), db.CountWorkspaceInvitationsParams{
+		WorkspaceID: actor.WorkspaceID,
+		Status:      status,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch invitations")
+		return
+	}
+
+	res := make([]InvitationResponse, len(invitations))
+	for i, inv := range invitations {
+		res[i] = toInvitationResponse(inv)
+	}
+
+	utils.JSON(w, http.StatusOK, PaginatedInvitationsResponse{
+		Data:   res,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// CreateInvitation emails an invitation to join the workspace. The address
+// does not need to belong to an account yet. An expired invitation to the
+// same address is replaced.
+func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		utils.Error(w, http.StatusBadRequest, "a valid email is required")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = authz.RoleMember
+	}
+	if req.Role != authz.RoleAdmin && req.Role != authz.RoleMember && req.Role != authz.RoleGuest {
+		utils.Error(w, http.StatusBadRequest, "role must be one of admin, member, guest")
+		return
+	}
+	if !canManage(actor.Role, req.Role) {
+		utils.Error(w, http.StatusForbidden, errCannotManage.Error())
+		return
+	}
+
+	if user, err := h.queries.GetUserByEmail(r.Context(), email); err == nil {
+		if _, err := h.queries.GetWorkspaceMember(r.Context(), db.GetWorkspaceMemberParams{
+			WorkspaceID: actor.WorkspaceID,
+			UserID:      user.ID,
+		}); err == nil {
+			utils.Error(w, http.StatusConflict, errAlreadyMember.Error())
+			return
+		}
+	}
+
+	rawToken, err := token.GenerateOpaque(32)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to create invitation")
+		return
+	}
+
+	inv, err := h.queries.CreateWorkspaceInvitation(r.Context(), db.CreateWorkspaceInvitationParams{
+		WorkspaceID: actor.WorkspaceID,
+		Email:       email,
+		Role:        req.Role,
+		InvitedBy:   pgtype.UUID{Bytes: actor.UserID, Valid: true},
+		TokenHash:   token.Hash(rawToken),
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().UTC().Add(invitationTTL), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusConflict, "an invitation to this email is already pending")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to create invitation")
+		return
+	}
+
+	if err := h.sendInvitation(r.Context(), actor.UserID, inv, rawToken); err != nil {
+		slog.Error("failed to send invitation email", "invitation_id", inv.ID, "error", err)
+	}
+
+	utils.JSON(w, http.StatusCreated, toInvitationResponse(inv))
+}
+
+// ResendInvitation emails a pending invitation again with a fresh link and
+// expiry. Earlier links stop working.
+func (h *Handler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	inv, ok := h.pendingInvitation(w, r, actor)
+	if !ok {
+		return
+	}
+
+	rawToken, err := token.GenerateOpaque(32)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to resend invitation")
+		return
+	}
+
+	inv, err = h.queries.RenewWorkspaceInvitation(r.Context(), db.RenewWorkspaceInvitationParams{
+		ID:        inv.ID,
+		TokenHash: token.Hash(rawToken),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().UTC().Add(invitationTTL), Valid: true},
+	})
+	if err != nil {
+		utils.Error(w, http.StatusConflict, "invitation is no longer pending")
+		return
+	}
+
+	if err := h.sendInvitation(r.Context(), actor.UserID, inv, rawToken); err != nil {
+		slog.Error("failed to send invitation email", "invitation_id", inv.ID, "error", err)
+		utils.Error(w, http.StatusInternalServerError, "failed to resend invitation")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, toInvitationResponse(inv))
+}
+
+// RevokeInvitation cancels a pending invitation so its link stops working.
+func (h *Handler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	inv, ok := h.pendingInvitation(w, r, actor)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.queries.RevokeWorkspaceInvitation(r.Context(), db.RevokeWorkspaceInvitationParams{
+		ID:          inv.ID,
+		WorkspaceID: inv.WorkspaceID,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to revoke invitation")
+		return
+	}
+	if revoked == 0 {
+		utils.Error(w, http.StatusConflict, "invitation is no longer pending")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pendingInvitation loads the invitation in the URL and checks that it is
+// still pending and that actor may manage it.
+func (h *Handler) pendingInvitation(w http.ResponseWriter, r *http.Request, actor db.WorkspaceMember) (db.WorkspaceInvitation, bool) {
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationID"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid invitation id")
+		return db.WorkspaceInvitation{}, false
+	}
+
+	inv, err := h.queries.GetWorkspaceInvitation(r.Context(), db.GetWorkspaceInvitationParams{
+		ID:          invitationID,
+		WorkspaceID: actor.WorkspaceID,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, "invitation not found")
+		return db.WorkspaceInvitation{}, false
+	}
+	if !canManage(actor.Role, inv.Role) {
+		utils.Error(w, http.StatusForbidden, errCannotManage.Error())
+		return db.WorkspaceInvitation{}, false
+	}
+	if inv.Status != InvitationPending {
+		utils.Error(w, http.StatusConflict, "invitation is no longer pending")
+		return db.WorkspaceInvitation{}, false
+	}
+	return inv, true
+}
+
+func (h *Handler) sendInvitation(ctx context.Context, inviterID uuid.UUID, inv db.WorkspaceInvitation, rawToken string) error {
+	ws, err := h.queries.GetWorkspaceByID(ctx, inv.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	inviter, err := h.queries.GetUserByID(ctx, inviterID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(inv.Email, mailer.TemplateWorkspaceInvitation, mailer.WorkspaceInvitationData{
+		InviterName:   inviter.Name,
+		WorkspaceName: ws.Name,
+		Role:          inv.Role,
+		AcceptURL:     strings.TrimRight(h.cfg.Primary.AppURL, "/") + "/invitations/accept?token=" + url.QueryEscape(rawToken),
+		ExpiresIn:     "7 days",
+	})
+	if err != nil {
+		return err
+	}
+	return h.mail.Send(ctx, msg)
+}
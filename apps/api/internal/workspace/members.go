@@ -0,0 +1,365 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+var (
+	errLastOwner     = errors.New("workspace must keep at least one owner")
+	errCannotManage  = errors.New("insufficient role to manage this member")
+	errAlreadyMember = errors.New("user is already a member")
+)
+
+type AddMemberRequest struct {
+	UserID *uuid.UUID `json:"user_id"`
+	Email  string     `json:"email"`
+	Role   string     `json:"role"`
+}
+
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type MemberResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	AvatarURL *string   `json:"avatar_url"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+type PaginatedMembersResponse struct {
+	Data   []MemberResponse `json:"data"`
+	Total  int64            `json:"total"`
+	Limit  int32            `json:"limit"`
+	Offset int32            `json:"offset"`
+}
+
+func toMemberResponse(m db.WorkspaceMember, u db.User) MemberResponse {
+	res := MemberResponse{
+		UserID:   m.UserID,
+		Name:     u.Name,
+		Email:    u.Email,
+		Role:     m.Role,
+		JoinedAt: m.JoinedAt.Time,
+	}
+	if u.AvatarUrl.Valid {
+		res.AvatarURL = &u.AvatarUrl.String
+	}
+	return res
+}
+
+// canManage reports whether a member with role actor may add, remove or
+// assign a member with role target. Owners manage everyone; admins manage
+// members and guests only.
+func canManage(actor, target string) bool {
+	switch actor {
+	case authz.RoleOwner:
+		return true
+	case authz.RoleAdmin:
+		return target == authz.RoleMember || target == authz.RoleGuest
+	}
+	return false
+}
+
+// ListMembers returns the workspace's members with their profiles.
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := utils.GetPagination(r, utils.DefaultPageLimit, utils.MaxPageLimit)
+
+	members, err := h.queries.ListWorkspaceMembers(r.Context(), db.ListWorkspaceMembersParams{
+		WorkspaceID: actor.WorkspaceID,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch members")
+		return
+	}
+
+	total, err := h.queries.CountWorkspaceMembers(r.Context(), actor.WorkspaceID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch members")
+		return
+	}
+
+	res := make([]MemberResponse, len(members))
+	for i, m := range members {
+		res[i] = MemberResponse{
+			UserID:   m.UserID,
+			Name:     m.Name,
+			Email:    m.Email,
+			Role:     m.Role,
+			JoinedAt: m.JoinedAt.Time,
+		}
+		if m.AvatarUrl.Valid {
+			res[i].AvatarURL = &m.AvatarUrl.String
+		}
+	}
+
+	utils.JSON(w, http.StatusOK, PaginatedMembersResponse{
+		Data:   res,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// AddMember adds an existing user, given by user_id or email, to the
+// workspace. The role defaults to member.
+func (h *Handler) AddMember(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	var req AddMemberRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = authz.RoleMember
+	}
+	if !authz.ValidRole(req.Role) {
+		utils.Error(w, http.StatusBadRequest, "role must be one of owner, admin, member, guest")
+		return
+	}
+	if !canManage(actor.Role, req.Role) {
+		utils.Error(w, http.StatusForbidden, errCannotManage.Error())
+		return
+	}
+
+	var (
+		user db.User
+		err  error
+	)
+	switch {
+	case req.UserID != nil:
+		user, err = h.queries.GetUserByID(r.Context(), *req.UserID)
+	case req.Email != "":
+		user, err = h.queries.GetUserByEmail(r.Context(), strings.ToLower(strings.TrimSpace(req.Email)))
+	default:
+		utils.Error(w, http.StatusBadRequest, "user_id or email is required")
+		return
+	}
+	if err != nil || user.Status == "deleted" {
+		utils.Error(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	member, err := h.queries.AddWorkspaceMember(r.Context(), db.AddWorkspaceMemberParams{
+		WorkspaceID: actor.WorkspaceID,
+		UserID:      user.ID,
+		Role:        req.Role,
+	})
+	if database.IsUniqueViolation(err) {
+		utils.Error(w, http.StatusConflict, errAlreadyMember.Error())
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to add member")
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, toMemberResponse(member, user))
+}
+
+// UpdateMemberRole changes a member's role. Only owners may grant or take
+// away the owner and admin roles, and the last owner cannot be demoted.
+func (h *Handler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var req UpdateMemberRoleRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !authz.ValidRole(req.Role) {
+		utils.Error(w, http.StatusBadRequest, "role must be one of owner, admin, member, guest")
+		return
+	}
+
+	var updated db.WorkspaceMember
+	err = h.changeMember(r.Context(), actor.WorkspaceID, targetID, func(q *db.Queries, ws db.Workspace, target db.WorkspaceMember) error {
+		if !canManage(actor.Role, target.Role) || !canManage(actor.Role, req.Role) {
+			return errCannotManage
+		}
+		if target.Role == authz.RoleOwner && req.Role != authz.RoleOwner {
+			if err := releaseOwner(r.Context(), q, ws, target.UserID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		updated, err = q.UpdateWorkspaceMemberRole(r.Context(), db.UpdateWorkspaceMemberRoleParams{
+			WorkspaceID: ws.ID,
+			UserID:      target.UserID,
+			Role:        req.Role,
+		})
+		return err
+	})
+	if err != nil {
+		writeMemberError(w, err, "failed to update member")
+		return
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), updated.UserID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to update member")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, toMemberResponse(updated, user))
+}
+
+// RemoveMember removes a member from the workspace. Members without the
+// right to manage others can still leave with LeaveWorkspace.
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	h.removeMember(w, r, actor, targetID)
+}
+
+// LeaveWorkspace removes the current user from the workspace. The last
+// owner has to hand ownership to someone else first.
+func (h *Handler) LeaveWorkspace(w http.ResponseWriter, r *http.Request) {
+	_, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	h.removeMember(w, r, actor, actor.UserID)
+}
+
+func (h *Handler) removeMember(w http.ResponseWriter, r *http.Request, actor db.WorkspaceMember, targetID uuid.UUID) {
+	err := h.changeMember(r.Context(), actor.WorkspaceID, targetID, func(q *db.Queries, ws db.Workspace, target db.WorkspaceMember) error {
+		if target.UserID != actor.UserID && !canManage(actor.Role, target.Role) {
+			return errCannotManage
+		}
+		if target.Role == authz.RoleOwner {
+			if err := releaseOwner(r.Context(), q, ws, target.UserID); err != nil {
+				return err
+			}
+		}
+
+		if err := q.RemoveWorkspaceMember(r.Context(), db.RemoveWorkspaceMemberParams{
+			WorkspaceID: ws.ID,
+			UserID:      target.UserID,
+		}); err != nil {
+			return err
+		}
+		return q.ClearUserDefaultWorkspace(r.Context(), db.ClearUserDefaultWorkspaceParams{
+			ID:                 target.UserID,
+			DefaultWorkspaceID: pgtype.UUID{Bytes: ws.ID, Valid: true},
+		})
+	})
+	if err != nil {
+		writeMemberError(w, err, "failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// changeMember runs fn in a transaction with the workspace row locked and the
+// target's membership freshly loaded, so concurrent changes cannot both see
+// a second owner and leave the workspace with none.
+func (h *Handler) changeMember(ctx context.Context, workspaceID, userID uuid.UUID, fn func(q *db.Queries, ws db.Workspace, target db.WorkspaceMember) error) error {
+	return database.WithTx(ctx, h.pool, func(q *db.Queries) error {
+		ws, err := q.GetWorkspaceForUpdate(ctx, workspaceID)
+		if err != nil {
+			return err
+		}
+
+		target, err := q.GetWorkspaceMember(ctx, db.GetWorkspaceMemberParams{
+			WorkspaceID: workspaceID,
+			UserID:      userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return fn(q, ws, target)
+	})
+}
+
+// releaseOwner is called before userID stops being an owner of ws. It fails
+// if they are the last owner, and otherwise hands the workspace's owner_id
+// to the longest-standing remaining owner if it pointed at them.
+func releaseOwner(ctx context.Context, q *db.Queries, ws db.Workspace, userID uuid.UUID) error {
+	owners, err := q.CountWorkspaceOwners(ctx, ws.ID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errLastOwner
+	}
+	if ws.OwnerID != userID {
+		return nil
+	}
+
+	next, err := q.GetNextWorkspaceOwner(ctx, db.GetNextWorkspaceOwnerParams{
+		WorkspaceID: ws.ID,
+		UserID:      userID,
+	})
+	if err != nil {
+		return err
+	}
+	return q.SetWorkspaceOwner(ctx, db.SetWorkspaceOwnerParams{
+		ID:      ws.ID,
+		OwnerID: next,
+	})
+}
+
+func writeMemberError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		utils.Error(w, http.StatusNotFound, "member not found")
+	case errors.Is(err, errCannotManage):
+		utils.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, errLastOwner):
+		utils.Error(w, http.StatusConflict, err.Error())
+	default:
+		utils.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,136 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+// maxSlugSuffix is the highest numbered suffix tried for a generated slug
+// before falling back to a random one.
+const maxSlugSuffix = 50
+
+var errSlugTaken = errors.New("slug is already taken")
+
+// GetWorkspaceBySlug returns the workspace with the given slug. A slug the
+// workspace used before redirects to its current one.
+func (h *Handler) GetWorkspaceBySlug(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	memberID, err := uuid.Parse(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "invalid user id")
+		return
+	}
+
+	slug := chi.URLParam(r, "slug")
+
+	ws, err := h.queries.GetWorkspaceBySlug(r.Context(), slug)
+	moved := false
+	if errors.Is(err, pgx.ErrNoRows) {
+		var workspaceID uuid.UUID
+		if workspaceID, err = h.queries.GetWorkspaceIDByOldSlug(r.Context(), slug); err == nil {
+			ws, err = h.queries.GetWorkspaceByID(r.Context(), workspaceID)
+			moved = true
+		}
+	}
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	// Only members learn where an old slug now points.
+	if _, err := h.queries.GetWorkspaceMember(r.Context(), db.GetWorkspaceMemberParams{
+		WorkspaceID: ws.ID,
+		UserID:      memberID,
+	}); err != nil {
+		utils.Error(w, http.StatusForbidden, "access denied")
+		return
+	}
+
+	if moved {
+		http.Redirect(w, r, path.Join(path.Dir(r.URL.Path), ws.Slug), http.StatusMovedPermanently)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, toResponse(ws))
+}
+
+// availableSlug returns base, or base with the lowest numbered suffix, such
+// that no other workspace uses it now or used it before and it is not
+// reserved. workspaceID is uuid.Nil for a workspace being created.
+func availableSlug(ctx context.Context, q *db.Queries, base string, workspaceID uuid.UUID) (string, error) {
+	if len(base) < utils.MinSlugLength {
+		base = utils.TruncateSlug("workspace-"+base, utils.MaxSlugLength)
+	}
+
+	for n := 1; n <= maxSlugSuffix; n++ {
+		slug := base
+		if n > 1 {
+			suffix := "-" + strconv.Itoa(n)
+			slug = utils.TruncateSlug(base, utils.MaxSlugLength-len(suffix)) + suffix
+		}
+		if utils.IsReservedSlug(slug) {
+			continue
+		}
+
+		taken, err := q.WorkspaceSlugTaken(ctx, db.WorkspaceSlugTakenParams{
+			Slug:        slug,
+			WorkspaceID: workspaceID,
+		})
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+	}
+
+	suffix := "-" + uuid.NewString()[:8]
+	return utils.TruncateSlug(base, utils.MaxSlugLength-len(suffix)) + suffix, nil
+}
+
+// claimSlug checks that a slug chosen by a user is free for workspaceID.
+func claimSlug(ctx context.Context, q *db.Queries, slug string, workspaceID uuid.UUID) error {
+	taken, err := q.WorkspaceSlugTaken(ctx, db.WorkspaceSlugTakenParams{
+		Slug:        slug,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errSlugTaken
+	}
+	return nil
+}
+
+// recordSlugChange keeps ws's current slug resolving after it moves to a
+// new one. Moving back to one of its own old slugs takes it out of the
+// history again.
+func recordSlugChange(ctx context.Context, q *db.Queries, ws db.Workspace, newSlug string) error {
+	if err := q.DeleteWorkspaceSlugHistory(ctx, db.DeleteWorkspaceSlugHistoryParams{
+		Slug:        newSlug,
+		WorkspaceID: ws.ID,
+	}); err != nil {
+		return err
+	}
+	return q.AddWorkspaceSlugHistory(ctx, db.AddWorkspaceSlugHistoryParams{
+		Slug:        ws.Slug,
+		WorkspaceID: ws.ID,
+	})
+}
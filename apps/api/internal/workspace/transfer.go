@@ -0,0 +1,73 @@
+package workspace
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/ownership"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+type TransferOwnershipRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// TransferOwnership hands the caller's ownership of the workspace to another
+// member. Any member with the owner role may do this, and they stay on as an
+// admin. Both users are emailed once the transfer has committed; a failed
+// email is logged rather than returned.
+func (h *Handler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	ws, actor, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+	if !authz.Can(actor.Role, authz.WorkspaceTransfer) {
+		utils.Error(w, http.StatusForbidden, "only a workspace owner can transfer ownership")
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.UserID == uuid.Nil {
+		utils.Error(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+
+	var transfer ownership.Transfer
+	err := database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		var err error
+		transfer, err = ownership.Move(r.Context(), q, ws.ID, actor.UserID, req.UserID)
+		return err
+	})
+	switch {
+	case errors.Is(err, ownership.ErrNewOwnerNotMember), errors.Is(err, ownership.ErrAlreadyOwner):
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, ownership.ErrNotOwner):
+		utils.Error(w, http.StatusForbidden, err.Error())
+		return
+	case errors.Is(err, pgx.ErrNoRows):
+		utils.Error(w, http.StatusNotFound, "workspace not found")
+		return
+	case err != nil:
+		utils.Error(w, http.StatusInternalServerError, "failed to transfer ownership")
+		return
+	}
+
+	if err := ownership.Notify(r.Context(), h.queries, h.mail, transfer); err != nil {
+		slog.Error("failed to send ownership transfer email", "workspace_id", ws.ID, "error", err)
+	}
+
+	utils.JSON(w, http.StatusOK, toResponse(transfer.Workspace))
+}
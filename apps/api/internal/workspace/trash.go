@@ -0,0 +1,381 @@
+package workspace
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "github.con/falasefemi2/taskflow/api/db/generated"
+	"github.con/falasefemi2/taskflow/api/internal/authz"
+	"github.con/falasefemi2/taskflow/api/internal/database"
+	"github.con/falasefemi2/taskflow/api/internal/middleware"
+	"github.con/falasefemi2/taskflow/api/internal/utils"
+)
+
+// Deleting a workspace, project or task stamps deleted_at on it and on
+// everything it contains that is not already in the trash, all with the same
+// time. Restoring a parent clears deleted_at on the rows that carry its
+// timestamp, so anything deleted on its own beforehand stays in the trash.
+
+type TrashedProjectResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type TrashedTaskResponse struct {
+	ID          uuid.UUID `json:"id"`
+	ProjectID   uuid.UUID `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Title       string    `json:"title"`
+	Status      string    `json:"status"`
+	DeletedAt   time.Time `json:"deleted_at"`
+}
+
+type PaginatedTrashedProjectsResponse struct {
+	Data   []TrashedProjectResponse `json:"data"`
+	Total  int64                    `json:"total"`
+	Limit  int32                    `json:"limit"`
+	Offset int32                    `json:"offset"`
+}
+
+type PaginatedTrashedTasksResponse struct {
+	Data   []TrashedTaskResponse `json:"data"`
+	Total  int64                 `json:"total"`
+	Limit  int32                 `json:"limit"`
+	Offset int32                 `json:"offset"`
+}
+
+// ListDeletedWorkspaces returns the workspaces in the trash that the caller
+// could restore, most recently deleted first. Members whose role does not
+// allow deleting a workspace do not see it in the trash.
+func (h *Handler) ListDeletedWorkspaces(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	memberID, err := uuid.Parse(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "invalid user id")
+		return
+	}
+
+	limit, offset := utils.GetPagination(r, utils.DefaultPageLimit, utils.MaxPageLimit)
+	roles := authz.RolesWith(authz.WorkspaceDelete)
+
+	workspaces, err := h.queries.ListDeletedWorkspacesForUser(r.Context(), db.ListDeletedWorkspacesForUserParams{
+		UserID:    memberID,
+		Roles:     roles,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch workspaces")
+		return
+	}
+
+	total, err := h.queries.CountDeletedWorkspacesForUser(r.Context(), db.CountDeletedWorkspacesForUserParams{
+		UserID: memberID,
+		Roles:  roles,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch workspaces")
+		return
+	}
+
+	res := make([]MembershipResponse, len(workspaces))
+	for i, ws := range workspaces {
+		res[i] = MembershipResponse{
+			WorkspaceResponse: toResponse(db.Workspace{
+				ID:          ws.ID,
+				Name:        ws.Name,
+				Slug:        ws.Slug,
+				Description: ws.Description,
+				OwnerID:     ws.OwnerID,
+				Status:      ws.Status,
+				CreatedAt:   ws.CreatedAt,
+				UpdatedAt:   ws.UpdatedAt,
+				DeletedAt:   ws.DeletedAt,
+			}),
+			Role: ws.Role,
+		}
+	}
+
+	utils.JSON(w, http.StatusOK, PaginatedWorkspacesResponse{
+		Data:   res,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// RestoreWorkspace takes a workspace out of the trash together with the
+// projects and tasks that were deleted with it. It runs without
+// LoadWorkspace, which only sees live workspaces, so it checks membership
+// and permission itself.
+func (h *Handler) RestoreWorkspace(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	memberID, err := uuid.Parse(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "invalid user id")
+		return
+	}
+
+	workspaceID, err := uuid.Parse(chi.URLParam(r, "workspaceID"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid workspace id")
+		return
+	}
+
+	ws, err := h.queries.GetDeletedWorkspaceByID(r.Context(), workspaceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusNotFound, "workspace not found in trash")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to restore workspace")
+		return
+	}
+
+	member, err := h.queries.GetWorkspaceMember(r.Context(), db.GetWorkspaceMemberParams{
+		WorkspaceID: ws.ID,
+		UserID:      memberID,
+	})
+	if err != nil || !authz.Can(member.Role, authz.WorkspaceDelete) {
+		utils.Error(w, http.StatusForbidden, "access denied")
+		return
+	}
+
+	var restored db.Workspace
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if err := q.RestoreTasksByWorkspaceID(r.Context(), db.RestoreTasksByWorkspaceIDParams{
+			WorkspaceID: ws.ID,
+			DeletedAt:   ws.DeletedAt,
+		}); err != nil {
+			return err
+		}
+		if err := q.RestoreProjectsByWorkspaceID(r.Context(), db.RestoreProjectsByWorkspaceIDParams{
+			WorkspaceID: ws.ID,
+			DeletedAt:   ws.DeletedAt,
+		}); err != nil {
+			return err
+		}
+
+		var err error
+		restored, err = q.RestoreWorkspace(r.Context(), ws.ID)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusNotFound, "workspace not found in trash")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to restore workspace")
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, toResponse(restored))
+}
+
+// ListTrashedProjects returns the workspace's deleted projects, most
+// recently deleted first.
+func (h *Handler) ListTrashedProjects(w http.ResponseWriter, r *http.Request) {
+	ws, _, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := utils.GetPagination(r, utils.DefaultPageLimit, utils.MaxPageLimit)
+
+	projects, err := h.queries.ListDeletedProjectsByWorkspaceID(r.Context(), db.ListDeletedProjectsByWorkspaceIDParams{
+		WorkspaceID: ws.ID,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch trash")
+		return
+	}
+
+	total, err := h.queries.CountDeletedProjectsByWorkspaceID(r.Context(), ws.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch trash")
+		return
+	}
+
+	res := make([]TrashedProjectResponse, len(projects))
+	for i, p := range projects {
+		res[i] = TrashedProjectResponse{
+			ID:        p.ID,
+			Name:      p.Name,
+			Status:    p.Status,
+			DeletedAt: p.DeletedAt.Time,
+		}
+	}
+
+	utils.JSON(w, http.StatusOK, PaginatedTrashedProjectsResponse{
+		Data:   res,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// ListTrashedTasks returns the workspace's deleted tasks, most recently
+// deleted first. Tasks of a deleted project are left out; they come back
+// with the project.
+func (h *Handler) ListTrashedTasks(w http.ResponseWriter, r *http.Request) {
+	ws, _, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := utils.GetPagination(r, utils.DefaultPageLimit, utils.MaxPageLimit)
+
+	tasks, err := h.queries.ListDeletedTasksByWorkspaceID(r.Context(), db.ListDeletedTasksByWorkspaceIDParams{
+		WorkspaceID: ws.ID,
+		RowLimit:    limit,
+		RowOffset:   offset,
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch trash")
+		return
+	}
+
+	total, err := h.queries.CountDeletedTasksByWorkspaceID(r.Context(), ws.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to fetch trash")
+		return
+	}
+
+	res := make([]TrashedTaskResponse, len(tasks))
+	for i, t := range tasks {
+		res[i] = TrashedTaskResponse{
+			ID:          t.ID,
+			ProjectID:   t.ProjectID,
+			ProjectName: t.ProjectName,
+			Title:       t.Title,
+			Status:      t.Status,
+			DeletedAt:   t.DeletedAt.Time,
+		}
+	}
+
+	utils.JSON(w, http.StatusOK, PaginatedTrashedTasksResponse{
+		Data:   res,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// RestoreProject takes a project out of the trash together with the tasks
+// that were deleted with it.
+func (h *Handler) RestoreProject(w http.ResponseWriter, r *http.Request) {
+	ws, _, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid project id")
+		return
+	}
+
+	project, err := h.queries.GetDeletedProject(r.Context(), db.GetDeletedProjectParams{
+		ID:          projectID,
+		WorkspaceID: ws.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusNotFound, "project not found in trash")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to restore project")
+		return
+	}
+
+	err = database.WithTx(r.Context(), h.pool, func(q *db.Queries) error {
+		if err := q.RestoreTasksByProjectID(r.Context(), db.RestoreTasksByProjectIDParams{
+			ProjectID: project.ID,
+			DeletedAt: project.DeletedAt,
+		}); err != nil {
+			return err
+		}
+
+		_, err := q.RestoreProject(r.Context(), project.ID)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusNotFound, "project not found in trash")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to restore project")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreTask takes a task out of the trash. Its project must not be in the
+// trash itself.
+func (h *Handler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	ws, _, ok := middleware.CurrentWorkspace(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, err := uuid.Parse(chi.URLParam(r, "taskID"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "invalid task id")
+		return
+	}
+
+	task, err := h.queries.GetDeletedTask(r.Context(), db.GetDeletedTaskParams{
+		ID:          taskID,
+		WorkspaceID: ws.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusNotFound, "task not found in trash")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to restore task")
+		return
+	}
+
+	_, err = h.queries.GetProjectByID(r.Context(), task.ProjectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusConflict, "restore the task's project first")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to restore task")
+		return
+	}
+
+	_, err = h.queries.RestoreTask(r.Context(), task.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Error(w, http.StatusNotFound, "task not found in trash")
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "failed to restore task")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}